@@ -32,9 +32,27 @@ If --base is provided, only publishes skills that have changed since that git re
 			return fmt.Errorf("--registry and --namespace are required for batch publishing")
 		}
 
+		layered, _ := cmd.Flags().GetBool("layered")
+		compressionFlag, _ := cmd.Flags().GetString("compression")
+		compression, err := store.ParseCompression(compressionFlag)
+		if err != nil {
+			return err
+		}
+		linksFlag, _ := cmd.Flags().GetString("links")
+		links, err := store.ParseLinkPolicy(linksFlag)
+		if err != nil {
+			return err
+		}
+
+		maxSizeFlag, _ := cmd.Flags().GetString("max-size")
+		maxSize, err := parseSize(maxSizeFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --max-size: %w", err)
+		}
+
 		// 1. Find all SKILL.md files
 		var skills []string
-		err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		err = filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
@@ -82,6 +100,16 @@ If --base is provided, only publishes skills that have changed since that git re
 			return nil
 		}
 
+		if listOnly(cmd) {
+			for _, skillPath := range skills {
+				fmt.Printf("\n%s:\n", skillPath)
+				if err := printFiles(skillPath, store.WithLinkPolicy(links)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
 		st, err := store.New("")
 		if err != nil {
 			return fmt.Errorf("failed to initialize store: %w", err)
@@ -123,7 +151,7 @@ If --base is provided, only publishes skills that have changed since that git re
 
 			for _, tag := range tags {
 				// Build (idempotent content-wise, just updates tag reference)
-				if err := st.Build(ctx, absPath, tag, nil); err != nil {
+				if _, err := st.Build(ctx, absPath, tag, nil, store.WithMaxSize(maxSize), store.WithLinkPolicy(links), store.WithLayered(layered), store.WithCompression(compression)); err != nil {
 					fmt.Printf("Build failure for %s: %v\n", tag, err)
 					errs = append(errs, fmt.Errorf("build failed for %s: %w", tag, err))
 					continue
@@ -153,6 +181,12 @@ func init() {
 	batchPublishCmd.Flags().String("registry", "", "Registry host (e.g. ghcr.io)")
 	batchPublishCmd.Flags().String("namespace", "", "Registry namespace (e.g. user or org)")
 	batchPublishCmd.Flags().String("repository", "", "Repository name (optional, enables repo.skill naming)")
+	batchPublishCmd.Flags().Bool("dry-run", false, "List the files that would be packaged for each skill without building or pushing")
+	batchPublishCmd.Flags().Bool("list-files", false, "Alias for --dry-run")
+	batchPublishCmd.Flags().String("compression", string(store.CompressionGzip), "Layer compression: gzip, zstd or none")
+	batchPublishCmd.Flags().Bool("layered", false, "Store SKILL.md, references/, scripts/ and assets/ in separate layers")
+	batchPublishCmd.Flags().String("links", string(store.LinksPreserve), "How to package symbolic links: preserve (links must stay inside the skill) or follow")
+	batchPublishCmd.Flags().String("max-size", "", "Maximum compressed artifact size per skill (e.g. 500KB, 10MB); unlimited if empty")
 	batchPublishCmd.MarkFlagRequired("registry")
 	batchPublishCmd.MarkFlagRequired("namespace")
 }
@@ -3,15 +3,26 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/andrewhowdencom/skr/pkg/git"
+	"github.com/andrewhowdencom/skr/pkg/resolution"
 	"github.com/andrewhowdencom/skr/pkg/skill"
 	"github.com/andrewhowdencom/skr/pkg/store"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
 )
 
-var buildTag string
+var (
+	buildTag         string
+	buildDryRun      bool
+	buildMaxSize     string
+	buildLinks       string
+	buildLayered     bool
+	buildCompression string
+)
 
 var buildCmd = &cobra.Command{
 	Use:   "build [path]",
@@ -21,6 +32,13 @@ var buildCmd = &cobra.Command{
 This command packages the skill definition and assets into an OCI-compatible
 image format, ready for distribution.
 
+Files matching the default excludes (.git, node_modules, editor swap files, ...)
+or the patterns in a .skrignore file at the root of the skill are not packaged.
+Use --dry-run (or --list-files) to list the files that would be included.
+
+The build fails if the estimated token count of the SKILL.md body or of a reference file
+exceeds a fail limit under "budgets" in .skr.yaml, and warns above a warn limit.
+
 If [path] is not provided, defaults to the current directory.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -33,10 +51,27 @@ If [path] is not provided, defaults to the current directory.`,
 		if err != nil {
 			return fmt.Errorf("failed to validate skill: %w", err)
 		}
+		if err := checkTokenBudgets(s.Path); err != nil {
+			return err
+		}
+
+		links, err := store.ParseLinkPolicy(buildLinks)
+		if err != nil {
+			return err
+		}
+
+		compression, err := store.ParseCompression(buildCompression)
+		if err != nil {
+			return err
+		}
+
+		if buildDryRun {
+			return printFiles(s.Path, store.WithLinkPolicy(links))
+		}
 
 		if buildTag == "" {
-			if s.Metadata.Author != "" && s.Metadata.Version != "" {
-				buildTag = fmt.Sprintf("%s/%s:%s", s.Metadata.Author, s.Name, s.Metadata.Version)
+			if s.Author() != "" && s.Version() != "" {
+				buildTag = fmt.Sprintf("%s/%s:%s", s.Author(), s.Name, s.Version())
 				fmt.Printf("No tag provided. Using metadata: %s\n", buildTag)
 			} else {
 				buildTag = fmt.Sprintf("%s:latest", s.Name)
@@ -44,6 +79,11 @@ If [path] is not provided, defaults to the current directory.`,
 			}
 		}
 
+		maxSize, err := parseSize(buildMaxSize)
+		if err != nil {
+			return fmt.Errorf("invalid --max-size: %w", err)
+		}
+
 		ctx := cmd.Context()
 		st, err := store.New("")
 		if err != nil {
@@ -52,21 +92,24 @@ If [path] is not provided, defaults to the current directory.`,
 
 		// Detect Git Remote for source annotation
 		annotations := make(map[string]string)
-		if sourceURL, err := getGitRemoteURL(); err == nil && sourceURL != "" {
+		if sourceURL, err := git.RemoteURL("."); err == nil && sourceURL != "" {
 			annotations["org.opencontainers.image.source"] = sourceURL
 			fmt.Printf("Detected git source: %s\n", sourceURL)
 		}
 
 		// Add Metadata Annotations
-		if s.Metadata.Author != "" {
-			annotations["com.skr.author"] = s.Metadata.Author
+		if author := s.Author(); author != "" {
+			annotations["com.skr.author"] = author
 		}
-		if s.Metadata.Version != "" {
-			annotations["com.skr.version"] = s.Metadata.Version
+		if version := s.Version(); version != "" {
+			annotations["com.skr.version"] = version
 		}
 		if s.Description != "" {
 			annotations["com.skr.description"] = s.Description
 		}
+		if s.License != "" {
+			annotations[ocispec.AnnotationLicenses] = s.License
+		}
 
 		// Add Dependencies Annotation
 		if len(s.Dependencies) > 0 {
@@ -74,15 +117,17 @@ If [path] is not provided, defaults to the current directory.`,
 			if err != nil {
 				return fmt.Errorf("failed to marshal dependencies: %w", err)
 			}
-			annotations["com.skr.dependencies"] = string(depsJSON)
+			annotations[resolution.DependenciesAnnotation] = string(depsJSON)
 		}
 
-		if err := st.Build(ctx, s.Path, buildTag, annotations); err != nil {
+		desc, err := st.Build(ctx, s.Path, buildTag, annotations, store.WithMaxSize(maxSize), store.WithLinkPolicy(links), store.WithLayered(buildLayered), store.WithCompression(compression))
+		if err != nil {
 			return fmt.Errorf("failed to build artifact: %w", err)
 		}
 
 		fmt.Printf("Successfully built artifact for skill '%s'\n", s.Name)
 		fmt.Printf("Tagged as: %s\n", buildTag)
+		fmt.Printf("Digest: %s\n", desc.Digest)
 
 		return nil
 	},
@@ -91,24 +136,82 @@ If [path] is not provided, defaults to the current directory.`,
 func init() {
 	rootCmd.AddCommand(buildCmd)
 	buildCmd.Flags().StringVarP(&buildTag, "tag", "t", "", "Tag for the built artifact (e.g., registry.com/skill:v1)")
+	buildCmd.Flags().BoolVar(&buildDryRun, "dry-run", false, "List the files that would be packaged without building")
+	buildCmd.Flags().BoolVar(&buildDryRun, "list-files", false, "Alias for --dry-run")
+	buildCmd.Flags().StringVar(&buildLinks, "links", string(store.LinksPreserve), "How to package symbolic links: preserve (links must stay inside the skill) or follow")
+	buildCmd.Flags().StringVar(&buildCompression, "compression", string(store.CompressionGzip), "Layer compression: gzip, zstd or none")
+	buildCmd.Flags().BoolVar(&buildLayered, "layered", false, "Store SKILL.md, references/, scripts/ and assets/ in separate layers")
+	buildCmd.Flags().StringVar(&buildMaxSize, "max-size", "", "Maximum compressed artifact size (e.g. 500KB, 10MB); unlimited if empty")
 }
 
-func getGitRemoteURL() (string, error) {
-	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
-	out, err := cmd.Output()
+// parseSize parses a byte size with an optional binary unit suffix (K, KB, M, MB, G, GB).
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{
+		{"GB", 1 << 30}, {"G", 1 << 30},
+		{"MB", 1 << 20}, {"M", 1 << 20},
+		{"KB", 1 << 10}, {"K", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(value, unit.suffix) {
+			multiplier = unit.factor
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a valid size", value)
+	}
+	return n * multiplier, nil
+}
+
+// listOnly reports whether --dry-run, or its alias --list-files, is set.
+func listOnly(cmd *cobra.Command) bool {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	listFiles, _ := cmd.Flags().GetBool("list-files")
+	return dryRun || listFiles
+}
+
+// printFiles prints the files that would be packaged from srcDir, one per line.
+func printFiles(srcDir string, opts ...store.BuildOption) error {
+	files, err := store.Files(srcDir, opts...)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to list files: %w", err)
 	}
-	url := strings.TrimSpace(string(out))
-	// Convert SSH URL to HTTPS if needed? GitHub packages supports both but HTTPS is standard for source linking?
-	// Actually GHCR works with repo URL.
-	// git@github.com:user/repo.git -> https://github.com/user/repo
-	if strings.HasPrefix(url, "git@github.com:") {
-		url = strings.Replace(url, "git@github.com:", "https://github.com/", 1)
-		url = strings.TrimSuffix(url, ".git")
-	} else if strings.HasPrefix(url, "https://github.com/") {
-		url = strings.TrimSuffix(url, ".git")
+	for _, f := range files {
+		fmt.Println(filepath.ToSlash(f))
+	}
+	return nil
+}
+
+// checkTokenBudgets prints the token budgets the skill in dir exceeds, failing if any of
+// them is exceeded past its fail limit.
+func checkTokenBudgets(dir string) error {
+	budgets, err := tokenBudgets(dir)
+	if err != nil {
+		return err
+	}
+	estimate, err := skill.EstimateSkillTokens(dir)
+	if err != nil {
+		return fmt.Errorf("failed to estimate tokens: %w", err)
 	}
 
-	return url, nil
+	diags := budgets.Check(dir, estimate)
+	for _, d := range diags {
+		fmt.Println(d)
+	}
+	if diags.HasErrors() {
+		return fmt.Errorf("skill exceeds its token budget")
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,10 +9,10 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
-	"strings"
 
 	"github.com/andrewhowdencom/skr/pkg/store"
 	"github.com/andrewhowdencom/skr/pkg/ui"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
 )
 
@@ -31,7 +32,7 @@ var httpGenerateCmd = &cobra.Command{
 		}
 
 		fmt.Println("Scanning OCI store...")
-		tags, err := st.List(ctx)
+		entries, err := st.Entries(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to list skills from store: %w", err)
 		}
@@ -48,27 +49,13 @@ var httpGenerateCmd = &cobra.Command{
 		}
 
 		// 3.1 _catalog
-		repoSet := make(map[string]bool)
-		repoTagsMap := make(map[string][]string)
-
-		for _, t := range tags {
-			// t is "repo:tag" or "repo"
-			var repo, tag string
-			if lastIdx := strings.LastIndex(t, ":"); lastIdx != -1 {
-				repo = t[:lastIdx]
-				tag = t[lastIdx+1:]
-			} else {
-				repo = t
-				tag = "latest" // Default if just repo? Or skip?
-			}
-			repoSet[repo] = true
-			if tag != "" {
-				repoTagsMap[repo] = append(repoTagsMap[repo], tag)
-			}
+		repoEntries := make(map[string][]store.Entry)
+		for _, entry := range entries {
+			repoEntries[entry.Repository] = append(repoEntries[entry.Repository], entry)
 		}
 
-		var repos []string
-		for r := range repoSet {
+		repos := []string{}
+		for r := range repoEntries {
 			repos = append(repos, r)
 		}
 		sort.Strings(repos)
@@ -92,9 +79,16 @@ var httpGenerateCmd = &cobra.Command{
 				return err
 			}
 
-			// Tags List
-			tagsList := repoTagsMap[repo]
-			sort.Strings(tagsList)
+			// Tags List; entries are sorted by tag. References stored without a tag have
+			// no manifest path to be served from.
+			var repoTagged []store.Entry
+			tagsList := []string{}
+			for _, entry := range repoEntries[repo] {
+				if entry.Tag != "" {
+					repoTagged = append(repoTagged, entry)
+					tagsList = append(tagsList, entry.Tag)
+				}
+			}
 
 			tagsDir := filepath.Join(repoDir, "tags")
 			if err := os.MkdirAll(tagsDir, 0755); err != nil {
@@ -116,14 +110,10 @@ var httpGenerateCmd = &cobra.Command{
 				return err
 			}
 
-			for _, tag := range tagsList {
+			for _, entry := range repoTagged {
 				// Fetch manifest content from store
-				ref := repo + ":" + tag
-				desc, err := st.Resolve(ctx, ref)
-				if err != nil {
-					fmt.Printf("Warning: could not resolve %s: %v\n", ref, err)
-					continue
-				}
+				ref := entry.Reference()
+				desc := entry.Descriptor
 
 				rc, err := st.Fetch(ctx, desc)
 				if err != nil {
@@ -131,7 +121,7 @@ var httpGenerateCmd = &cobra.Command{
 					continue
 				}
 
-				manifestPath := filepath.Join(manifestsDir, tag)
+				manifestPath := filepath.Join(manifestsDir, entry.Tag)
 				manifestFile, err := os.Create(manifestPath)
 				if err != nil {
 					rc.Close()
@@ -144,6 +134,11 @@ var httpGenerateCmd = &cobra.Command{
 				if err != nil {
 					return err
 				}
+
+				// The UI reads skill metadata from the config blob
+				if err := writeConfigBlob(ctx, st, desc, filepath.Join(repoDir, "blobs")); err != nil {
+					fmt.Printf("Warning: could not write config for %s: %v\n", ref, err)
+				}
 			}
 		}
 
@@ -171,6 +166,36 @@ func init() {
 	httpGenerateCmd.Flags().StringVarP(&outputDir, "output", "o", "build/http", "Directory to output the generated site")
 }
 
+// writeConfigBlob writes the skill config referenced by the manifest desc into blobsDir.
+func writeConfigBlob(ctx context.Context, st *store.Store, desc v1.Descriptor, blobsDir string) error {
+	manifest, err := st.Manifest(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if manifest.Config.MediaType != store.MediaTypeSkillConfig {
+		return nil
+	}
+
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return err
+	}
+
+	rc, err := st.Fetch(ctx, manifest.Config)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(filepath.Join(blobsDir, manifest.Config.Digest.String()))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, rc)
+	return err
+}
+
 func writeJSON(path string, data interface{}) error {
 	f, err := os.Create(path)
 	if err != nil {
@@ -196,23 +196,18 @@ func newOCIHandler(GlobalCtx context.Context, st *store.Store, proxy *httputil.R
 
 		// 4.2 Extensions: Catalog
 		if path == "_catalog" {
-			tags, err := st.List(ctx)
+			entries, err := st.Entries(ctx)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Failed to list tags: %v", err), http.StatusInternalServerError)
 				return
 			}
 
 			repoSet := make(map[string]bool)
-			for _, t := range tags {
-				// repo:tag
-				if lastIdx := strings.LastIndex(t, ":"); lastIdx != -1 {
-					repoSet[t[:lastIdx]] = true
-				} else {
-					repoSet[t] = true
-				}
+			for _, entry := range entries {
+				repoSet[entry.Repository] = true
 			}
 
-			var repos []string
+			repos := []string{}
 			for repo := range repoSet {
 				repos = append(repos, repo)
 			}
@@ -228,29 +223,23 @@ func newOCIHandler(GlobalCtx context.Context, st *store.Store, proxy *httputil.R
 		if strings.HasSuffix(path, "/tags/list") {
 			name := strings.TrimSuffix(path, "/tags/list")
 
-			tags, err := st.List(ctx)
+			entries, err := st.Entries(ctx, store.WithRepository(name))
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Failed to list tags: %v", err), http.StatusInternalServerError)
 				return
 			}
 
-			var repoTags []string
-			foundRepo := false
-
-			prefix := name + ":"
-			for _, t := range tags {
-				if strings.HasPrefix(t, prefix) {
-					foundRepo = true
-					repoTags = append(repoTags, strings.TrimPrefix(t, prefix))
-				} else if t == name {
-					foundRepo = true
-				}
-			}
-
-			if !foundRepo {
+			if len(entries) == 0 {
 				http.Error(w, "Repository not found", http.StatusNotFound)
 				return
 			}
+
+			repoTags := []string{}
+			for _, entry := range entries {
+				if entry.Tag != "" {
+					repoTags = append(repoTags, entry.Tag)
+				}
+			}
 			sort.Strings(repoTags)
 
 			response := map[string]interface{}{
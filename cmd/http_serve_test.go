@@ -8,6 +8,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/andrewhowdencom/skr/pkg/store"
@@ -95,7 +96,64 @@ func TestServeOCIEndpoints(t *testing.T) {
 		checkHeaders(t, w)
 	})
 
-	// 3. Test Proxy Mode (Simulated)
+	// 3. Test Catalog and Tags List for a repository with a registry port
+	t.Run("Tags List With Registry Port", func(t *testing.T) {
+		tmpDir, st := createTestStore(t)
+		defer os.RemoveAll(tmpDir)
+
+		ctx := context.Background()
+		srcDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: skill\ndescription: A skill\n---\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, ref := range []string{"localhost:5000/skill:v1", "localhost:5000/skill"} {
+			if _, err := st.Build(ctx, srcDir, ref, nil); err != nil {
+				t.Fatalf("Failed to build %s: %v", ref, err)
+			}
+		}
+
+		handler := newOCIHandler(ctx, st, nil)
+
+		req := httptest.NewRequest("GET", "/v2/_catalog", nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		var catalog map[string][]string
+		if err := json.NewDecoder(w.Body).Decode(&catalog); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if got := catalog["repositories"]; len(got) != 1 || got[0] != "localhost:5000/skill" {
+			t.Errorf("Expected [localhost:5000/skill], got %v", got)
+		}
+
+		req = httptest.NewRequest("GET", "/v2/localhost:5000/skill/tags/list", nil)
+		w = httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 OK, got %d", w.Code)
+		}
+		var tags struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&tags); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(tags.Tags) != 1 || tags.Tags[0] != "v1" {
+			t.Errorf("Expected tags [v1], got %v", tags.Tags)
+		}
+
+		req = httptest.NewRequest("GET", "/v2/localhost/tags/list", nil)
+		w = httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 Not Found, got %d", w.Code)
+		}
+	})
+
+	// 4. Test Proxy Mode (Simulated)
 	// We can pass a proxy that points to a test server
 	t.Run("Proxy Mode", func(t *testing.T) {
 		// Mock upstream
@@ -9,6 +9,8 @@ import (
 	"github.com/andrewhowdencom/skr/pkg/action"
 	"github.com/andrewhowdencom/skr/pkg/config"
 	"github.com/andrewhowdencom/skr/pkg/discovery"
+	"github.com/andrewhowdencom/skr/pkg/parallel"
+	"github.com/andrewhowdencom/skr/pkg/resolution"
 	"github.com/andrewhowdencom/skr/pkg/store"
 	"github.com/spf13/cobra"
 )
@@ -19,13 +21,27 @@ var installCmd = &cobra.Command{
 	Long: `Install an Agent Skill.
 
 Adds the skill to the configuration (.skr.yaml) and synchronizes the installation.
-If --global is set, installs to the global configuration.`,
+If --global is set, installs to the global configuration.
+
+Dependencies are installed too. If they include different versions of the same repository,
+or different skills with the same name, --conflicts decides which one is installed: fail
+reports them, highest chooses the highest semantic version tag, and root chooses the one
+nearest to the skill being installed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return fmt.Errorf("requires skill reference (e.g. tag or digest)")
 		}
 		ref := args[0]
 		isGlobal, _ := cmd.Flags().GetBool("global")
+		conflicts, _ := cmd.Flags().GetString("conflicts")
+		policy, err := resolution.ParseConflictPolicy(conflicts)
+		if err != nil {
+			return err
+		}
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		if jobs < 1 {
+			return fmt.Errorf("invalid --jobs %d: must be at least 1", jobs)
+		}
 		ctx := cmd.Context()
 
 		// 1. Determine Context and Load Config
@@ -109,7 +125,7 @@ If --global is set, installs to the global configuration.`,
 		}
 
 		slog.Info("installing skill", "skill", ref, "path", installRoot)
-		name, err := action.InstallSkill(ctx, st, ref, installRoot)
+		name, err := action.InstallSkill(ctx, st, ref, installRoot, action.WithConflictPolicy(policy), action.WithJobs(jobs))
 		if err != nil {
 			return err
 		}
@@ -121,5 +137,7 @@ If --global is set, installs to the global configuration.`,
 
 func init() {
 	installCmd.Flags().Bool("global", false, "Install skill globally")
+	installCmd.Flags().String("conflicts", string(resolution.PolicyFail), "How to resolve dependencies on different versions of a skill: fail, highest or root")
+	installCmd.Flags().IntP("jobs", "j", parallel.DefaultJobs, "Number of skills to resolve, pull and unpack at once")
 	rootCmd.AddCommand(installCmd)
 }
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andrewhowdencom/skr/pkg/action"
+	"github.com/andrewhowdencom/skr/pkg/registry"
+	"github.com/andrewhowdencom/skr/pkg/scaffold"
+	"github.com/andrewhowdencom/skr/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newTemplate    string
+	newDir         string
+	newDescription string
+	newAuthor      string
+	newLicense     string
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Create a new Agent Skill from a template",
+	Long: `Create a new Agent Skill directory named <name>, with a SKILL.md file and the
+references/, scripts/ and assets/ directories.
+
+The --template flag selects where the files come from:
+- the name of a built-in template (` + strings.Join(scaffold.BuiltinNames(), ", ") + `)
+- a local directory
+- an OCI reference to a skill artifact, which is pulled into the local store
+
+Template files ending in .tmpl are rendered with Go's text/template, with the fields
+.Name, .Description, .Author and .License; the suffix is removed. Other files are copied
+as they are. The name in SKILL.md is always set to <name>, so any published skill can be
+used as a template.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		dest := filepath.Join(newDir, name)
+
+		tmpl, cleanup, err := loadTemplate(cmd.Context(), newTemplate)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		data := scaffold.Data{
+			Name:        name,
+			Description: newDescription,
+			Author:      newAuthor,
+			License:     newLicense,
+		}
+		if err := tmpl.Create(dest, data); err != nil {
+			return fmt.Errorf("failed to create skill: %w", err)
+		}
+
+		fmt.Printf("Created skill '%s' in %s\n", name, dest)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(newCmd)
+	newCmd.Flags().StringVar(&newTemplate, "template", scaffold.DefaultTemplate, "Built-in template name, local directory or OCI reference")
+	newCmd.Flags().StringVar(&newDir, "dir", ".", "Directory to create the skill in")
+	newCmd.Flags().StringVar(&newDescription, "description", "", "Description of the skill")
+	newCmd.Flags().StringVar(&newAuthor, "author", "", "Author recorded in the skill metadata")
+	newCmd.Flags().StringVar(&newLicense, "license", "", "License of the skill (e.g. MIT)")
+}
+
+// loadTemplate returns the template named by source: a built-in template, a local
+// directory or an OCI reference. The returned function removes any temporary files.
+func loadTemplate(ctx context.Context, source string) (*scaffold.Template, func(), error) {
+	noop := func() {}
+
+	if scaffold.IsBuiltin(source) {
+		tmpl, err := scaffold.Builtin(source)
+		return tmpl, noop, err
+	}
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return scaffold.FromDir(source), noop, nil
+	}
+
+	st, err := store.New("")
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to initialize store: %w", err)
+	}
+	fmt.Printf("Pulling template %s...\n", source)
+	if err := registry.Pull(ctx, st, source); err != nil {
+		return nil, noop, fmt.Errorf("template %q is not a built-in template, a directory or a pullable reference: %w", source, err)
+	}
+
+	dir, err := os.MkdirTemp("", "skr-template-*")
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	if err := action.Extract(ctx, st, source, dir); err != nil {
+		cleanup()
+		return nil, noop, fmt.Errorf("failed to extract template %s: %w", source, err)
+	}
+	return scaffold.FromDir(dir), cleanup, nil
+}
@@ -22,10 +22,31 @@ var publishSkillCmd = &cobra.Command{
 		}
 
 		tag, _ := cmd.Flags().GetString("tag")
+		layered, _ := cmd.Flags().GetBool("layered")
+		compressionFlag, _ := cmd.Flags().GetString("compression")
+		compression, err := store.ParseCompression(compressionFlag)
+		if err != nil {
+			return err
+		}
+		linksFlag, _ := cmd.Flags().GetString("links")
+		links, err := store.ParseLinkPolicy(linksFlag)
+		if err != nil {
+			return err
+		}
+
+		if listOnly(cmd) {
+			return printFiles(srcDir, store.WithLinkPolicy(links))
+		}
 		if tag == "" {
 			return fmt.Errorf("a tag is required for publishing (e.g. --tag ghcr.io/user/skill:v1)")
 		}
 
+		maxSizeFlag, _ := cmd.Flags().GetString("max-size")
+		maxSize, err := parseSize(maxSizeFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --max-size: %w", err)
+		}
+
 		// 1. Build
 		absPath, err := filepath.Abs(srcDir)
 		if err != nil {
@@ -45,7 +66,7 @@ var publishSkillCmd = &cobra.Command{
 		}
 
 		fmt.Printf("Building skill from %s...\n", srcDir)
-		if err := st.Build(ctx, absPath, tag, annotations); err != nil {
+		if _, err := st.Build(ctx, absPath, tag, annotations, store.WithMaxSize(maxSize), store.WithLinkPolicy(links), store.WithLayered(layered), store.WithCompression(compression)); err != nil {
 			return fmt.Errorf("failed to build artifact: %w", err)
 		}
 		fmt.Printf("Successfully built %s\n", tag)
@@ -64,6 +85,11 @@ var publishSkillCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(publishSkillCmd)
-	publishSkillCmd.Flags().StringP("tag", "t", "", "Tag for the artifact (required)")
-	publishSkillCmd.MarkFlagRequired("tag")
+	publishSkillCmd.Flags().StringP("tag", "t", "", "Tag for the artifact (required unless listing files)")
+	publishSkillCmd.Flags().Bool("dry-run", false, "List the files that would be packaged without building or pushing")
+	publishSkillCmd.Flags().Bool("list-files", false, "Alias for --dry-run")
+	publishSkillCmd.Flags().String("compression", string(store.CompressionGzip), "Layer compression: gzip, zstd or none")
+	publishSkillCmd.Flags().Bool("layered", false, "Store SKILL.md, references/, scripts/ and assets/ in separate layers")
+	publishSkillCmd.Flags().String("links", string(store.LinksPreserve), "How to package symbolic links: preserve (links must stay inside the skill) or follow")
+	publishSkillCmd.Flags().String("max-size", "", "Maximum compressed artifact size (e.g. 500KB, 10MB); unlimited if empty")
 }
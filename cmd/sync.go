@@ -9,6 +9,8 @@ import (
 	"github.com/andrewhowdencom/skr/pkg/action"
 	"github.com/andrewhowdencom/skr/pkg/config"
 	"github.com/andrewhowdencom/skr/pkg/discovery"
+	"github.com/andrewhowdencom/skr/pkg/parallel"
+	"github.com/andrewhowdencom/skr/pkg/resolution"
 	"github.com/andrewhowdencom/skr/pkg/store"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +24,16 @@ var syncCmd = &cobra.Command{
 - Removes skills in .agent/skills that are not present in .skr.yaml (unless they are local dependencies/ignored, TBD).
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		conflicts, _ := cmd.Flags().GetString("conflicts")
+		policy, err := resolution.ParseConflictPolicy(conflicts)
+		if err != nil {
+			return err
+		}
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		if jobs < 1 {
+			return fmt.Errorf("invalid --jobs %d: must be at least 1", jobs)
+		}
+
 		cwd, err := os.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get cwd: %w", err)
@@ -65,45 +77,21 @@ var syncCmd = &cobra.Command{
 			return fmt.Errorf("failed to create install root %s: %w", installRoot, err)
 		}
 
-		// 4. Install missing skills
-		// Naive implementation: iterate config.Skills, checked if installed.
-		// NOTE: config.Skills might be "git:v1" or just "git".
-		// We need to resolve name.
-		for _, ref := range cfg.Skills {
-			// Check if installed
-			// We need to parse name from ref?
-			// If ref is "git:v1", name is "git".
-			// If ref is "ghcr.io/mypkg/git:latest", name is "git"?
-			// Ideally config should map name -> ref, OR we parse ref.
-			// Simple parsing: last component before tag.
-			// For now, let's assume ref is compatible with what we expect.
-			// Wait, how do we know if it's already installed?
-			// By checking directory existence?
-
-			// FIXME: name resolution is tricky without pulling.
-			// Let's rely on 'install' logic which unpacks to temp and gets name.
-			// For sync, efficiency matters.
-			// IF we mandate ref format <name>:<tag> or just <name>, we can guess.
-			// But for now, let's just attempt install if NOT skipping?
-
-			// Actually, let's call the install logic directly.
-			// Reusing logic from installCmd would be good.
-			// Moving install logic to a pkg/action or similar?
-			// For now, inline or copy/paste logic from install.go.
-
-			slog.Info("syncing skill", "ref", ref)
-
-			// Install using the action package
-			_, err := action.InstallSkill(ctx, st, ref, installRoot)
-			if err != nil {
-				return fmt.Errorf("failed to install %s: %w", ref, err)
-			}
+		// 4. Install the skills. Their dependencies are resolved together, so that shared
+		// dependencies are pulled and installed once, and conflicts between them are found.
+		slog.Info("syncing skills", "count", len(cfg.Skills), "jobs", jobs)
+		names, err := action.InstallSkills(ctx, st, cfg.Skills, installRoot, action.WithConflictPolicy(policy), action.WithJobs(jobs))
+		if err != nil {
+			return err
 		}
+		slog.Info("synced skills", "installed", len(names))
 
 		return nil
 	},
 }
 
 func init() {
+	syncCmd.Flags().String("conflicts", string(resolution.PolicyFail), "How to resolve dependencies on different versions of a skill: fail, highest or root")
+	syncCmd.Flags().IntP("jobs", "j", parallel.DefaultJobs, "Number of skills to resolve, pull and unpack at once")
 	rootCmd.AddCommand(syncCmd)
 }
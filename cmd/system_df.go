@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var systemDfCmd = &cobra.Command{
+	Use:   "df",
+	Short: "Show disk usage of the local store",
+	Long: `Show how the local store uses disk space.
+
+Sizes count the real size of every blob (manifests, configs and layers) once, however many
+tags reference it:
+
+  Total        every blob in the store
+  Reachable    blobs referenced by at least one tag
+  Reclaimable  blobs that 'skr system prune' would delete
+  Shared       blobs referenced by more than one tag
+  Extracted    the cache of extracted layers that installs link from, not part of Total
+
+Each repository is listed with the size of the blobs its tags reference, and how much of that
+is shared with other repositories.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "table" && format != "json" {
+			return fmt.Errorf("invalid --format %q: must be table or json", format)
+		}
+
+		st, err := store.New("")
+		if err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+
+		usage, err := st.DiskUsage(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to measure disk usage: %w", err)
+		}
+
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(usage)
+		}
+
+		fmt.Printf("%-13s %s (%d blobs)\n", "Total:", formatBytes(usage.Total), usage.Blobs)
+		fmt.Printf("%-13s %s\n", "Reachable:", formatBytes(usage.Reachable))
+		fmt.Printf("%-13s %s\n", "Reclaimable:", formatBytes(usage.Reclaimable))
+		fmt.Printf("%-13s %s\n", "Shared:", formatBytes(usage.Shared))
+		fmt.Printf("%-13s %s\n", "Extracted:", formatBytes(usage.Extracted))
+
+		fmt.Printf("\n%-40s %-6s %-12s %-12s %-12s\n", "REPOSITORY", "TAGS", "SIZE", "SHARED", "UNIQUE")
+		for _, repo := range usage.Repositories {
+			fmt.Printf("%-40s %-6d %-12s %-12s %-12s\n", repo.Repository, repo.Tags,
+				formatBytes(repo.Size), formatBytes(repo.Shared), formatBytes(repo.Size-repo.Shared))
+		}
+		return nil
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemDfCmd)
+	systemDfCmd.Flags().String("format", "table", "Output format: table or json")
+}
+
+// formatBytes renders a byte count with a binary unit.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.2f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.2f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
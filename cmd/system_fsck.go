@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var systemFsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Verify the integrity of the local store",
+	Long: `Verify the integrity of the local store.
+
+Re-hashes every blob and checks that every tag resolves to a parseable manifest whose
+config and layers exist and are intact, and that the cache of extracted layers still
+matches the layers. Reports corrupted, truncated and missing blobs, invalid manifests,
+damaged extractions and orphaned blobs.
+
+With --repair, tags referencing damaged artifacts are removed and corrupt blobs are
+deleted, so that the artifacts can be pulled or built again. Damaged extractions are
+deleted, and extracted again by the next install.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repair, _ := cmd.Flags().GetBool("repair")
+		ctx := cmd.Context()
+
+		st, err := store.New("")
+		if err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+
+		report, err := st.Verify(ctx, repair)
+		if err != nil {
+			return fmt.Errorf("failed to verify store: %w", err)
+		}
+
+		fmt.Printf("Checked %d blobs and %d tags\n", report.BlobsChecked, report.TagsChecked)
+
+		for _, p := range report.Problems {
+			subject := p.Digest.String()
+			if p.Tag != "" {
+				subject = fmt.Sprintf("%s (%s)", p.Tag, p.Digest)
+			}
+			fmt.Printf("%-18s %s: %s\n", p.Kind, subject, p.Detail)
+		}
+
+		if len(report.Orphans) > 0 {
+			fmt.Printf("Found %d orphaned blobs (run 'skr system prune' to remove them)\n", len(report.Orphans))
+		}
+
+		for _, tag := range report.RemovedTags {
+			fmt.Printf("Removed tag %s\n", tag)
+		}
+		for _, d := range report.RemovedBlobs {
+			fmt.Printf("Removed corrupt blob %s\n", d)
+		}
+		for _, d := range report.RemovedExtractions {
+			fmt.Printf("Removed cached extraction %s\n", d)
+		}
+
+		if report.Healthy() {
+			fmt.Println("No problems found.")
+			return nil
+		}
+		if repair {
+			fmt.Println("Repair complete. Pull or rebuild the removed artifacts.")
+			return nil
+		}
+		return fmt.Errorf("found %d problems (run 'skr system fsck --repair' to remove damaged tags)", len(report.Problems))
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemFsckCmd)
+	systemFsckCmd.Flags().Bool("repair", false, "Remove tags referencing damaged artifacts and delete corrupt blobs")
+}
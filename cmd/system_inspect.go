@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/andrewhowdencom/skr/pkg/skill"
 	"github.com/andrewhowdencom/skr/pkg/store"
-	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
 )
 
@@ -38,27 +41,23 @@ Can be used on local artifacts to see details like dependencies, version, and ma
 		fmt.Printf("MediaType: %s\n", desc.MediaType)
 
 		// 2. Fetch Manifest
-		rc, err := st.Fetch(ctx, desc)
+		manifest, err := st.Manifest(ctx, desc)
 		if err != nil {
 			return fmt.Errorf("failed to fetch manifest: %w", err)
 		}
-		defer rc.Close()
-
-		manifestBytes, err := io.ReadAll(rc)
-		if err != nil {
-			return fmt.Errorf("failed to read manifest: %w", err)
-		}
-
-		var manifest ocispec.Manifest
-		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-			return fmt.Errorf("failed to parse manifest: %w", err)
-		}
 
 		// Print Annotations
 		if len(manifest.Annotations) > 0 {
 			fmt.Println("\nAnnotations:")
-			for k, v := range manifest.Annotations {
-				fmt.Printf("  %s: %s\n", k, v)
+			for _, k := range sortedKeys(manifest.Annotations) {
+				fmt.Printf("  %s: %s\n", k, manifest.Annotations[k])
+			}
+		}
+
+		if body, ok := manifest.Annotations[skill.AnnotationTokensBody]; ok {
+			fmt.Printf("\nEstimated Tokens:\n  SKILL.md body: %s\n", body)
+			if references, ok := manifest.Annotations[skill.AnnotationTokensReferences]; ok {
+				fmt.Printf("  References: %s\n", references)
 			}
 		}
 
@@ -67,22 +66,17 @@ Can be used on local artifacts to see details like dependencies, version, and ma
 		fmt.Printf("  Digest: %s\n", manifest.Config.Digest)
 		fmt.Printf("  MediaType: %s\n", manifest.Config.MediaType)
 
-		// Optionally fetch config content to show creation time etc.
-		configRc, err := st.Fetch(ctx, manifest.Config)
-		if err == nil {
-			defer configRc.Close()
-			configBytes, _ := io.ReadAll(configRc)
-			var configMap map[string]interface{}
-			if err := json.Unmarshal(configBytes, &configMap); err == nil {
-				if created, ok := configMap["created"]; ok {
-					fmt.Printf("  Created: %v\n", created)
-				}
-			}
+		config, err := st.Config(ctx, manifest)
+		if err != nil && !errors.Is(err, store.ErrNotSkillConfig) {
+			return err
+		}
+		if config != nil {
+			printConfig(config)
 		}
 
 		fmt.Printf("\nLayers: %d\n", len(manifest.Layers))
 		for i, layer := range manifest.Layers {
-			fmt.Printf("  [%d] %s (%d bytes)\n", i, layer.Digest, layer.Size)
+			fmt.Printf("  [%d] %s (%d bytes, %s)\n", i, layer.Digest, layer.Size, layer.MediaType)
 		}
 
 		return nil
@@ -92,3 +86,100 @@ Can be used on local artifacts to see details like dependencies, version, and ma
 func init() {
 	systemCmd.AddCommand(inspectCmd)
 }
+
+// printConfig prints the contents of a skill config blob.
+func printConfig(config *store.Config) {
+	if !config.Created.IsZero() {
+		fmt.Printf("  Created: %s\n", config.Created.Format(time.RFC3339))
+	}
+	if config.SchemaVersion == 0 {
+		// Built before the config schema existed; metadata lives in the annotations.
+		return
+	}
+	fmt.Printf("  Schema Version: %d\n", config.SchemaVersion)
+	if name := config.Name(); name != "" {
+		fmt.Printf("  Name: %s\n", name)
+	}
+	if description := config.Description(); description != "" {
+		fmt.Printf("  Description: %s\n", description)
+	}
+	if s, err := config.Skill(); err == nil {
+		printFrontmatter(s)
+	}
+	fmt.Printf("  Body Size: %d bytes\n", config.BodySize)
+
+	p := config.Provenance
+	fmt.Printf("  Builder: %s\n", p.Builder)
+	if p.Source != "" {
+		fmt.Printf("  Source: %s\n", p.Source)
+	}
+	if p.Revision != "" {
+		fmt.Printf("  Revision: %s\n", p.Revision)
+	}
+
+	if len(config.Dependencies) > 0 {
+		fmt.Println("\nDependencies:")
+		for _, dep := range config.Dependencies {
+			fmt.Printf("  %s\n", dep)
+		}
+	}
+
+	fmt.Printf("\nFiles: %d\n", len(config.Files))
+	for _, f := range config.Files {
+		switch f.Type {
+		case store.FileTypeSymlink:
+			fmt.Printf("  %s -> %s\n", f.Path, f.Linkname)
+		default:
+			mode := "-rw-r--r--"
+			if f.Executable {
+				mode = "-rwxr-xr-x"
+			}
+			id := f.Digest.Encoded()
+			if len(id) > 12 {
+				id = id[:12]
+			}
+			fmt.Printf("  %s %8d %-12s %s\n", mode, f.Size, id, f.Path)
+		}
+	}
+}
+
+// printFrontmatter prints the optional frontmatter fields of a skill, including keys
+// that skr does not know about.
+func printFrontmatter(s *skill.Skill) {
+	if s.License != "" {
+		fmt.Printf("  License: %s\n", s.License)
+	}
+	if s.Compatibility != "" {
+		fmt.Printf("  Compatibility: %s\n", s.Compatibility)
+	}
+	if len(s.AllowedTools) > 0 {
+		fmt.Printf("  Allowed Tools: %s\n", strings.Join(s.AllowedTools, " "))
+	}
+
+	if len(s.Metadata) > 0 {
+		fmt.Println("  Metadata:")
+		for _, k := range sortedKeys(s.Metadata) {
+			fmt.Printf("    %s: %s\n", k, s.Metadata[k])
+		}
+	}
+
+	if len(s.Extra) > 0 {
+		fmt.Println("  Other Frontmatter:")
+		for _, k := range sortedKeys(s.Extra) {
+			value, err := json.Marshal(s.Extra[k])
+			if err != nil {
+				value = []byte(fmt.Sprint(s.Extra[k]))
+			}
+			fmt.Printf("    %s: %s\n", k, value)
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,18 +2,18 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/andrewhowdencom/skr/pkg/store"
 	"github.com/spf13/cobra"
 )
 
 var systemListCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [repository]",
 	Short: "List built/pulled artifacts in Local Registry",
 	Long: `List all skill artifacts stored in the local OCI registry.
 	
-Shows repository, tag, digest, and size.`,
+Shows repository, tag, digest, and size. Pass a repository to only list its tags.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 		st, err := store.New("")
@@ -21,7 +21,12 @@ Shows repository, tag, digest, and size.`,
 			return fmt.Errorf("failed to initialize store: %w", err)
 		}
 
-		tags, err := st.List(ctx)
+		var opts []store.EntriesOption
+		if len(args) == 1 {
+			opts = append(opts, store.WithRepository(args[0]))
+		}
+
+		entries, err := st.Entries(ctx, opts...)
 		if err != nil {
 			return fmt.Errorf("failed to list skills: %w", err)
 		}
@@ -29,36 +34,19 @@ Shows repository, tag, digest, and size.`,
 		// Header
 		fmt.Printf("%-30s %-15s %-15s %-10s\n", "REPOSITORY", "TAG", "IMAGE ID", "SIZE")
 
-		for _, tag := range tags {
-			// Resolve to get digest and size
-			desc, err := st.Resolve(ctx, tag)
-			if err != nil {
-				// warn and continue?
-				continue
-			}
-
-			// For REPOSITORY/TAG splitting, we assume standard "repo:tag" format.
-			repo := tag
-			version := "<none>"
-
-			if idx := lastIndex(tag, ":"); idx != -1 {
-				repo = tag[:idx]
-				version = tag[idx+1:]
+		for _, entry := range entries {
+			version := entry.Tag
+			if version == "" {
+				version = "<none>"
 			}
 
 			// Short digest
-			digestVal := desc.Digest.String()
-			if len(digestVal) > 12 {
-				digestVal = digestVal[7:19] // sha256:1234... -> 1234...
+			digestVal := entry.Digest.Encoded()
+			if len(digestVal) > store.MinDigestPrefix {
+				digestVal = digestVal[:store.MinDigestPrefix]
 			}
 
-			// Size (human readable-ish)
-			size := fmt.Sprintf("%d B", desc.Size)
-			if desc.Size > 1024 {
-				size = fmt.Sprintf("%.2f KB", float64(desc.Size)/1024)
-			}
-
-			fmt.Printf("%-30s %-15s %-15s %-10s\n", repo, version, digestVal, size)
+			fmt.Printf("%-30s %-15s %-15s %-10s\n", entry.Repository, version, digestVal, formatBytes(entry.Size))
 		}
 
 		return nil
@@ -68,7 +56,3 @@ Shows repository, tag, digest, and size.`,
 func init() {
 	systemCmd.AddCommand(systemListCmd)
 }
-
-func lastIndex(s, sep string) int {
-	return strings.LastIndex(s, sep)
-}
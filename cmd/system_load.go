@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var systemLoadCmd = &cobra.Command{
+	Use:   "load -i <file>",
+	Short: "Import skills from an OCI layout archive",
+	Long: `Import every tagged skill from a tarball in the OCI image-layout format, such as one
+written by 'skr system save', into the local store.
+
+Use '-i -' to read the archive from standard input.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, _ := cmd.Flags().GetString("input")
+		if input == "" {
+			return fmt.Errorf("an input file is required (use -i)")
+		}
+
+		// The archive is read with random access, so buffer stdin to a file
+		if input == "-" {
+			tmp, err := os.CreateTemp("", "skr-load-*")
+			if err != nil {
+				return fmt.Errorf("failed to buffer archive: %w", err)
+			}
+			defer os.Remove(tmp.Name())
+			if _, err := io.Copy(tmp, os.Stdin); err != nil {
+				tmp.Close()
+				return fmt.Errorf("failed to buffer archive: %w", err)
+			}
+			if err := tmp.Close(); err != nil {
+				return fmt.Errorf("failed to buffer archive: %w", err)
+			}
+			input = tmp.Name()
+		}
+
+		st, err := store.New("")
+		if err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+
+		tags, err := st.Load(cmd.Context(), input)
+		if err != nil {
+			return fmt.Errorf("failed to load skills: %w", err)
+		}
+
+		for _, tag := range tags {
+			fmt.Printf("Loaded %s\n", tag)
+		}
+		return nil
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemLoadCmd)
+	systemLoadCmd.Flags().StringP("input", "i", "", "Archive file to read ('-' for stdin)")
+}
@@ -2,7 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/andrewhowdencom/skr/pkg/config"
+	"github.com/andrewhowdencom/skr/pkg/resolution"
 	"github.com/andrewhowdencom/skr/pkg/store"
 	"github.com/spf13/cobra"
 )
@@ -10,21 +16,83 @@ import (
 var systemPruneCmd = &cobra.Command{
 	Use:   "prune",
 	Short: "Remove unused data",
-	Long:  `Remove unused data (blob garbage collection). This command deletes any content in the local store that is not referenced by any tag.`,
+	Long: `Remove unused data (blob garbage collection). This command deletes any content in the local store that is not referenced by any tag.
+
+Retention rules remove tags before collecting blobs. Each rule is applied independently,
+and a tag is removed if any rule selects it:
+
+  --keep-last N     keep only the N most recent tags of each repository
+  --older-than AGE  remove tags created more than AGE ago (e.g. 72h, 30d)
+  --unused          remove tags not listed in the project .skr.yaml, the global
+                    configuration, or any file passed with --config; their
+                    dependencies and tags matching a version constraint are kept
+
+Use --dry-run to list the tags and blobs that would be removed.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		keepLast, _ := cmd.Flags().GetInt("keep-last")
+		olderThanFlag, _ := cmd.Flags().GetString("older-than")
+		unused, _ := cmd.Flags().GetBool("unused")
+		configPaths, _ := cmd.Flags().GetStringSlice("config")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		var opts []store.PruneOption
+		if keepLast < 0 {
+			return fmt.Errorf("--keep-last must not be negative")
+		}
+		if keepLast > 0 {
+			opts = append(opts, store.WithKeepLast(keepLast))
+		}
+		if olderThanFlag != "" {
+			olderThan, err := parseAge(olderThanFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --older-than: %w", err)
+			}
+			opts = append(opts, store.WithOlderThan(olderThan))
+		}
+		if dryRun {
+			opts = append(opts, store.WithDryRun())
+		}
+
 		st, err := store.New("")
 		if err != nil {
 			return fmt.Errorf("failed to initialize store: %w", err)
 		}
 
 		ctx := cmd.Context()
-		count, size, err := st.Prune(ctx)
+		if unused || len(configPaths) > 0 {
+			refs, err := referencedSkills(configPaths)
+			if err != nil {
+				return err
+			}
+			// Keep what the configured skills depend on, and every tag matching a constraint.
+			refs, err = resolution.New(st).Referenced(ctx, refs)
+			if err != nil {
+				return fmt.Errorf("failed to resolve referenced skills: %w", err)
+			}
+			opts = append(opts, store.WithReferenced(refs))
+		}
+		report, err := st.Prune(ctx, opts...)
 		if err != nil {
 			return fmt.Errorf("failed to prune system: %w", err)
 		}
 
-		fmt.Printf("Deleted %d artifacts\n", count)
-		fmt.Printf("Reclaimed %.2f MB\n", float64(size)/1024/1024)
+		if dryRun {
+			for _, tag := range report.Tags {
+				fmt.Printf("Would remove tag %s\n", tag)
+			}
+			for _, blob := range report.Blobs {
+				fmt.Printf("Would delete %s (%d bytes)\n", blob.Digest, blob.Size)
+			}
+			fmt.Printf("Would reclaim %.2f MB\n", float64(report.Reclaimed)/1024/1024)
+			return nil
+		}
+
+		for _, tag := range report.Tags {
+			fmt.Printf("Removed tag %s\n", tag)
+		}
+		fmt.Printf("Deleted %d artifacts\n", len(report.Blobs))
+		fmt.Printf("Reclaimed %.2f MB\n", float64(report.Reclaimed)/1024/1024)
 
 		return nil
 	},
@@ -32,4 +100,48 @@ var systemPruneCmd = &cobra.Command{
 
 func init() {
 	systemCmd.AddCommand(systemPruneCmd)
+	systemPruneCmd.Flags().Int("keep-last", 0, "Keep only the N most recent tags per repository")
+	systemPruneCmd.Flags().String("older-than", "", "Remove tags created longer ago than this (e.g. 72h, 30d)")
+	systemPruneCmd.Flags().Bool("unused", false, "Remove tags not referenced by the project or global configuration")
+	systemPruneCmd.Flags().StringSlice("config", nil, "Additional .skr.yaml files whose skills are kept (implies --unused)")
+	systemPruneCmd.Flags().Bool("dry-run", false, "List what would be removed without deleting anything")
+}
+
+// parseAge parses a duration, additionally accepting a number of days (e.g. "30d").
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%q is not a valid number of days", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// referencedSkills returns the skills listed in the merged configuration of the current
+// project and in each of the given config files.
+func referencedSkills(configPaths []string) ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	cfg, err := config.LoadMerged(cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := append([]string{}, cfg.Skills...)
+	for _, path := range configPaths {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		other, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, other.Skills...)
+	}
+	return refs, nil
 }
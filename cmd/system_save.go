@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+var systemSaveCmd = &cobra.Command{
+	Use:   "save <ref>... -o <file>",
+	Short: "Export skills to an OCI layout archive",
+	Long: `Export one or more skills from the local store to a tarball in the OCI image-layout format.
+
+The archive contains the selected tags with their manifests, configs and layers, and can be
+imported on another machine with 'skr system load', for example in an air-gapped environment.
+Each reference must name a tag, which the archive records it under; a reference pinned to a
+digest, such as repo:v1@sha256:..., is saved under its tag. Use '-o -' to write the archive to
+standard output.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			return fmt.Errorf("an output file is required (use -o)")
+		}
+
+		st, err := store.New("")
+		if err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+
+		ctx := cmd.Context()
+		if output == "-" {
+			return st.Save(ctx, os.Stdout, args...)
+		}
+
+		// Write to a temporary file first so a failed save does not leave a partial archive
+		tmp, err := os.CreateTemp(filepath.Dir(output), ".skr-save-*")
+		if err != nil {
+			return fmt.Errorf("failed to create archive: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+
+		if err := st.Save(ctx, tmp, args...); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to save skills: %w", err)
+		}
+		if err := tmp.Close(); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		if err := os.Chmod(tmp.Name(), 0644); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), output); err != nil {
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+
+		for _, ref := range args {
+			fmt.Printf("Saved %s\n", ref)
+		}
+		return nil
+	},
+}
+
+func init() {
+	systemCmd.AddCommand(systemSaveCmd)
+	systemSaveCmd.Flags().StringP("output", "o", "", "Archive file to write ('-' for stdout)")
+}
@@ -3,17 +3,59 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/andrewhowdencom/skr/pkg/store"
 	"github.com/spf13/cobra"
 )
 
 var tagCmd = &cobra.Command{
-	Use:   "tag",
+	Use:   "tag <source-ref|digest> <target-ref>...",
 	Short: "Create a tag for a local Agent Skill",
 	Long: `Tag a local Agent Skill image with a new name and/or tag.
 
-This is mostly used to prepare a built skill for pushing to a specific registry.`,
+This is mostly used to prepare a built skill for pushing to a specific registry.
+
+The source can be a tag, a digest, or a unique prefix of a digest of at least 12 characters,
+such as the IMAGE ID shown by 'skr system list'. Targets without a tag are tagged 'latest'.`,
+	Example: `  skr system tag my-skill:v1 ghcr.io/org/my-skill:v1 ghcr.io/org/my-skill:latest
+  skr system tag 3f2a9c1b7d4e ghcr.io/org/my-skill:v1`,
+	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return fmt.Errorf("not yet implemented")
+		source, targets := args[0], args[1:]
+
+		// Validate every target before tagging any of them
+		var refs []string
+		for _, target := range targets {
+			ref, err := store.ParseReference(target)
+			if err != nil {
+				return err
+			}
+			if ref.Digest != "" {
+				return fmt.Errorf("invalid target %q: cannot tag with a digest", target)
+			}
+			if ref.Tag == "" {
+				ref.Tag = "latest"
+			}
+			refs = append(refs, ref.String())
+		}
+
+		ctx := cmd.Context()
+		st, err := store.New("")
+		if err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+
+		desc, err := st.Lookup(ctx, source)
+		if err != nil {
+			return fmt.Errorf("reference %s not found: %w", source, err)
+		}
+
+		for _, ref := range refs {
+			if err := st.Tag(ctx, desc, ref); err != nil {
+				return fmt.Errorf("failed to tag %s: %w", ref, err)
+			}
+			fmt.Printf("Tagged %s as %s\n", desc.Digest, ref)
+		}
+		return nil
 	},
 }
 
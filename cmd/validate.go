@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
 
+	"github.com/andrewhowdencom/skr/pkg/config"
 	"github.com/andrewhowdencom/skr/pkg/skill"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +23,15 @@ Checks for:
 - Spec compliance (naming, fields)
 - Directory structure
 
+Every finding is reported with a rule ID, a severity (error, warning or info), the file
+and, where known, the line and column. Only errors make the skill invalid.
+
+The estimated token counts of the SKILL.md body and of each reference file are checked
+against the budgets under "budgets" in .skr.yaml.
+
+Use --format json or --format sarif to produce machine-readable output, for example to
+annotate pull requests in CI.
+
 If [path] is not provided, defaults to the current directory.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -26,16 +40,191 @@ If [path] is not provided, defaults to the current directory.`,
 			path = args[0]
 		}
 
-		s, err := skill.Load(path)
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" && format != "sarif" {
+			return fmt.Errorf("invalid --format %q: must be text, json or sarif", format)
+		}
+
+		budgets, err := tokenBudgets(path)
 		if err != nil {
-			return fmt.Errorf("skill is invalid: %w", err)
+			return err
+		}
+
+		s, diags := skill.Check(path, skill.WithTokenBudgets(budgets))
+		if diags == nil {
+			diags = skill.Diagnostics{}
+		}
+
+		switch format {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(validateReport{Path: path, Valid: !diags.HasErrors(), Diagnostics: diags}); err != nil {
+				return err
+			}
+		case "sarif":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(newSARIFLog(diags)); err != nil {
+				return err
+			}
+		default:
+			for _, d := range diags {
+				fmt.Println(d)
+			}
+			if !diags.HasErrors() {
+				fmt.Printf("Skill '%s' is valid.\n", s.Name)
+			}
 		}
 
-		fmt.Printf("Skill '%s' is valid.\n", s.Name)
+		if n := diags.Count(skill.SeverityError); n > 0 {
+			return fmt.Errorf("skill is invalid: %d error(s)", n)
+		}
 		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().String("format", "text", "Output format: text, json or sarif")
+}
+
+// tokenBudgets returns the token budgets for the skill in dir: the defaults, overridden by
+// the budgets in the global config and in the .skr.yaml above dir.
+func tokenBudgets(dir string) (skill.TokenBudgets, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return skill.TokenBudgets{}, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	cfg, err := config.LoadMerged(abs)
+	if err != nil {
+		return skill.TokenBudgets{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	return skill.DefaultTokenBudgets.Merge(cfg.Budgets), nil
+}
+
+// validateReport is the JSON output of 'skr validate'.
+type validateReport struct {
+	Path        string            `json:"path"`
+	Valid       bool              `json:"valid"`
+	Diagnostics skill.Diagnostics `json:"diagnostics"`
+}
+
+// SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/), limited to the fields
+// code scanning tools need to annotate files.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// newSARIFLog converts diagnostics to a SARIF log with a single run.
+func newSARIFLog(diags skill.Diagnostics) sarifLog {
+	driver := sarifDriver{
+		Name:           "skr",
+		InformationURI: "https://github.com/andrewhowdencom/skr",
+		Rules:          make([]sarifRule, 0, len(skill.Rules)),
+	}
+	for _, rule := range skill.Rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: sarifURI(d.File)}}
+		if d.Line > 0 {
+			location.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+		results = append(results, sarifResult{
+			RuleID:    d.Rule,
+			Level:     sarifLevel(d.Severity),
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(severity skill.Severity) string {
+	switch severity {
+	case skill.SeverityError:
+		return "error"
+	case skill.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifURI returns relative paths as relative URIs, so that they resolve against the
+// repository root, and absolute paths as file URIs.
+func sarifURI(path string) string {
+	if filepath.IsAbs(path) {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	}
+	return (&url.URL{Path: filepath.ToSlash(filepath.Clean(path))}).String()
 }
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/andrewhowdencom/skr/pkg/skill"
+)
+
+func TestNewSARIFLog(t *testing.T) {
+	log := newSARIFLog(skill.Diagnostics{
+		{Rule: "name-format", Severity: skill.SeverityError, File: "skills/pdf/SKILL.md", Line: 2, Message: "bad name"},
+		{Rule: "license", Severity: skill.SeverityInfo, File: "skills/pdf/SKILL.md", Message: "no license"},
+	})
+
+	if len(log.Runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(log.Runs))
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(skill.Rules) {
+		t.Errorf("got %d rules, want %d", len(run.Tool.Driver.Rules), len(skill.Rules))
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(run.Results))
+	}
+
+	first := run.Results[0]
+	if first.RuleID != "name-format" || first.Level != "error" {
+		t.Errorf("first result = %s/%s, want name-format/error", first.RuleID, first.Level)
+	}
+	location := first.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "skills/pdf/SKILL.md" || location.Region == nil || location.Region.StartLine != 2 {
+		t.Errorf("first location = %+v, want skills/pdf/SKILL.md line 2", location)
+	}
+
+	second := run.Results[1]
+	if second.Level != "note" || second.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("second result = %+v, want a note without a region", second)
+	}
+}
@@ -0,0 +1,205 @@
+package action
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// materialize writes the files of a skill artifact into dest.
+//
+// Each layer is extracted once into the store's extraction cache (see store.ExtractDir)
+// and its files are installed from there by reflink, hardlink or, failing both, a copy,
+// so installing the same skill into many projects is cheap. Cached files are read-only as
+// a guard against accidental edits; a hardlinked install still shares its files with the
+// cache, so an edit that makes one writable alters the cache, which 'skr system fsck'
+// detects and repairs. Stores without a cache extract the layers directly into dest.
+func materialize(ctx context.Context, st *store.Store, manifest ocispec.Manifest, dest string) error {
+	if len(manifest.Layers) == 0 || st.ExtractDir(manifest.Layers[0].Digest) == "" {
+		return unpackManifest(ctx, st, manifest, dest)
+	}
+	for _, layer := range manifest.Layers {
+		if !store.IsSkillLayer(layer.MediaType) {
+			return fmt.Errorf("unsupported layer media type %q", layer.MediaType)
+		}
+	}
+
+	// Keep a concurrent prune from removing the cache while it is being read.
+	unlock, err := st.RLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// As when unpacking, links are created once every layer is installed, so that nothing
+	// is written through a link that an earlier layer restored.
+	var links []*tar.Header
+	for _, layer := range manifest.Layers {
+		dir := st.ExtractDir(layer.Digest)
+		if err := extractLayer(ctx, st, layer, dir); err != nil {
+			return fmt.Errorf("failed to unpack layer %s: %w", layer.Digest, err)
+		}
+		layerLinks, err := linkTree(dir, dest)
+		if err != nil {
+			return fmt.Errorf("failed to install layer %s: %w", layer.Digest, err)
+		}
+		links = append(links, layerLinks...)
+	}
+	names, err := createSymlinks(dest, links)
+	if err != nil {
+		return err
+	}
+	return checkSymlinks(dest, names)
+}
+
+// extractLayer extracts layer into dir, unless it has been extracted already. The layer is
+// verified against its digest before it is added to the cache.
+func extractLayer(ctx context.Context, st *store.Store, layer ocispec.Descriptor, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	// Extract next to the cache entry and rename it into place, so that concurrent
+	// installs never see a partial tree.
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".tmp-"+layer.Digest.Encoded()+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	rc, err := st.Fetch(ctx, layer)
+	if err != nil {
+		return fmt.Errorf("failed to fetch layer: %w", err)
+	}
+	defer rc.Close()
+	vr := content.NewVerifyReader(rc, layer)
+
+	u := &unpacker{dest: tmp, partial: true}
+	if err := u.add(vr, layer.MediaType); err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, vr); err != nil {
+		return err
+	}
+	if err := vr.Verify(); err != nil {
+		return err
+	}
+	if err := u.finish(); err != nil {
+		return err
+	}
+
+	if err := makeReadOnly(tmp); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return nil // extracted by another install in the meantime
+		}
+		return err
+	}
+	return nil
+}
+
+// makeReadOnly removes the write permission from every regular file under dir.
+func makeReadOnly(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.Chmod(path, info.Mode().Perm()&^0222)
+	})
+}
+
+// linkTree installs the directories and files of the tree at src into dst, and returns its
+// symbolic links for the caller to create with createSymlinks. Nothing is written through
+// a link already in dst.
+func linkTree(src, dst string) ([]*tar.Header, error) {
+	var links []*tar.Header
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			links = append(links, &tar.Header{
+				Typeflag: tar.TypeSymlink,
+				Name:     filepath.ToSlash(rel),
+				Linkname: filepath.ToSlash(link),
+			})
+			return nil
+		}
+
+		if rel != "." {
+			parent, err := symlinkParent(dst, rel)
+			if err != nil {
+				return err
+			}
+			if parent != "" {
+				return fmt.Errorf("cannot install %s: its parent %s is a symlink", filepath.ToSlash(rel), filepath.ToSlash(parent))
+			}
+		}
+
+		if d.IsDir() {
+			// Replace a link below dst rather than following it.
+			if existing, err := os.Lstat(target); rel != "." && err == nil && !existing.IsDir() {
+				if err := os.Remove(target); err != nil {
+					return err
+				}
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm()|0700)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		return linkFile(path, target, info.Mode().Perm())
+	})
+	return links, err
+}
+
+// linkFile installs src at dst by reflink or hardlink, falling back to a copy. Reflinks and
+// copies are independent of the cache, so they get back the write permission that the
+// cache removed.
+func linkFile(src, dst string, mode os.FileMode) error {
+	if err := reflink(src, dst, mode|0200); err == nil {
+		return nil
+	}
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return copyFile(src, dst, mode|0200)
+}
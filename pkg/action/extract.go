@@ -0,0 +1,21 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+)
+
+// Extract writes the files of the skill artifact ref, which must be in the store, to dest.
+func Extract(ctx context.Context, st *store.Store, ref, dest string) error {
+	desc, err := st.Resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	manifest, err := st.Manifest(ctx, desc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	return materialize(ctx, st, manifest, dest)
+}
@@ -1,15 +1,15 @@
 package action
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/andrewhowdencom/skr/pkg/parallel"
 	"github.com/andrewhowdencom/skr/pkg/registry"
 	"github.com/andrewhowdencom/skr/pkg/resolution"
 	"github.com/andrewhowdencom/skr/pkg/skill"
@@ -17,40 +17,94 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+type installOptions struct {
+	conflictPolicy resolution.ConflictPolicy
+	jobs           int
+}
+
+// InstallOption configures a call to InstallSkill or InstallSkills.
+type InstallOption func(*installOptions)
+
+// WithConflictPolicy sets how conflicting dependencies are resolved.
+func WithConflictPolicy(policy resolution.ConflictPolicy) InstallOption {
+	return func(o *installOptions) { o.conflictPolicy = policy }
+}
+
+// WithJobs sets how many skills are resolved, pulled and unpacked at once. The default is
+// parallel.DefaultJobs.
+func WithJobs(jobs int) InstallOption {
+	return func(o *installOptions) { o.jobs = jobs }
+}
+
 // InstallSkill installs a skill and its dependencies from the store to the installDir.
-func InstallSkill(ctx context.Context, st *store.Store, ref, installDir string) (string, error) {
-	// 1. Resolve all dependencies
+// It returns the name of the skill.
+func InstallSkill(ctx context.Context, st *store.Store, ref, installDir string, opts ...InstallOption) (string, error) {
+	names, err := InstallSkills(ctx, st, []string{ref}, installDir, opts...)
+	if err != nil {
+		return "", err
+	}
+	// The first one in the resolved list is the root skill (BFS start)
+	return names[0], nil
+}
+
+// InstallSkills installs skills and their dependencies from the store to the installDir.
+// The dependencies of all skills are resolved together, so that each is installed once and
+// conflicts between them are found. It returns the names of the installed skills, in the
+// order they were resolved.
+func InstallSkills(ctx context.Context, st *store.Store, refs []string, installDir string, opts ...InstallOption) ([]string, error) {
+	options := installOptions{jobs: parallel.DefaultJobs}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	// 1. Resolve all dependencies
 	resolver := resolution.New(st)
 	resolver.SetPuller(func(ctx context.Context, ref string) error {
 		fmt.Printf("Pulling missing dependency %s...\n", ref)
 		return registry.Pull(ctx, st, ref)
 	})
+	resolver.SetTagLister(registry.Tags)
+	resolver.SetConflictPolicy(options.conflictPolicy)
+	resolver.SetConflictReporter(func(c resolution.Conflict) {
+		fmt.Printf("Warning: %s\n", c)
+	})
+	resolver.SetJobs(options.jobs)
 
-	refs, err := resolver.Resolve(ctx, ref)
+	resolved, err := resolver.ResolveAll(ctx, refs)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve dependencies for %s: %w", ref, err)
+		return nil, fmt.Errorf("failed to resolve dependencies for %s: %w", strings.Join(refs, ", "), err)
 	}
 
-	var rootName string
-
-	// 2. Install each skill (sequentially for now)
-	for i, r := range refs {
-		name, err := installOne(ctx, st, r, installDir)
+	// 2. Install each skill. Skills are installed into separate directories, as conflicts
+	// have been resolved, so they can be unpacked concurrently.
+	names := make([]string, len(resolved))
+	err = parallel.ForEach(ctx, options.jobs, len(resolved), func(ctx context.Context, i int) error {
+		name, err := installOne(ctx, st, resolved[i], installDir, resolver.Pulled(resolved[i]))
 		if err != nil {
-			return "", fmt.Errorf("failed to install %s: %w", r, err)
+			return fmt.Errorf("failed to install %s: %w", resolved[i], err)
 		}
+		names[i] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
 
-		// The first one in the resolved list is the root skill (BFS start)
-		if i == 0 {
-			rootName = name
+// installOne installs ref from the store into installDir, pulling it first if it is missing
+// or tagged latest. pulled reports whether the resolver has already pulled it in this run.
+func installOne(ctx context.Context, st *store.Store, ref, installDir string, pulled bool) (string, error) {
+	// A reference pinned to a digest, such as a dependency chosen by a version constraint,
+	// is installed by its digest, which the resolver has already pulled into the store.
+	if parsed, err := store.ParseReference(ref); err == nil && parsed.Digest != "" {
+		desc, err := st.Resolve(ctx, parsed.Digest.String())
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
 		}
+		return installManifest(ctx, st, desc, installDir)
 	}
 
-	return rootName, nil
-}
-
-func installOne(ctx context.Context, st *store.Store, ref, installDir string) (string, error) {
 	// 1. Resolve Reference locally
 	desc, err := st.Resolve(ctx, ref)
 	shouldPull := false
@@ -66,7 +120,7 @@ func installOne(ctx context.Context, st *store.Store, ref, installDir string) (s
 		// Naive check for :latest suffix.
 		// NOTE: 'ref' might be fully qualified or short.
 		if len(ref) > 7 && ref[len(ref)-7:] == ":latest" {
-			shouldPull = true
+			shouldPull = !pulled
 		}
 	}
 
@@ -90,6 +144,11 @@ func installOne(ctx context.Context, st *store.Store, ref, installDir string) (s
 		}
 	}
 
+	return installManifest(ctx, st, desc, installDir)
+}
+
+// installManifest unpacks the skill artifact with the manifest desc into installDir.
+func installManifest(ctx context.Context, st *store.Store, desc ocispec.Descriptor, installDir string) (string, error) {
 	// 2. Fetch Manifest
 	manifestReader, err := st.Fetch(ctx, desc)
 	if err != nil {
@@ -107,31 +166,23 @@ func installOne(ctx context.Context, st *store.Store, ref, installDir string) (s
 		return "", fmt.Errorf("failed to parse manifest: %w", err)
 	}
 
-	if len(manifest.Layers) != 1 {
-		return "", fmt.Errorf("expected exactly 1 layer, got %d", len(manifest.Layers))
+	// 3. Unpack Layers to Temp. The temp dir is created next to installDir, rather than in
+	// it, so that an unfinished skill is never visible to agents, while files can still be
+	// hardlinked from the extraction cache and renamed into place on the same filesystem.
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create install dir: %w", err)
 	}
-
-	layerDesc := manifest.Layers[0]
-
-	// 3. Fetch Layer
-	layerReader, err := st.Fetch(ctx, layerDesc)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch layer: %w", err)
-	}
-	defer layerReader.Close()
-
-	// 4. Unpack Layer to Temp
-	tempDir, err := os.MkdirTemp("", "skr-install-*")
+	tempDir, err := os.MkdirTemp(filepath.Dir(installDir), "."+filepath.Base(installDir)+".skr-install-*")
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp dir: %w", err)
 	}
 	defer os.RemoveAll(tempDir)
 
-	if err := unpackLayer(layerReader, tempDir); err != nil {
-		return "", fmt.Errorf("failed to unpack layer: %w", err)
+	if err := materialize(ctx, st, manifest, tempDir); err != nil {
+		return "", err
 	}
 
-	// 5. Read SKILL.md to get the name
+	// 4. Read SKILL.md to get the name
 	s, err := skill.LoadUnverified(tempDir)
 	if err != nil {
 		// If we can't even load it (missing file, invalid yaml), we still fail as we need the name.
@@ -148,7 +199,7 @@ func installOne(ctx context.Context, st *store.Store, ref, installDir string) (s
 		return "", fmt.Errorf("failed to create parent dir: %w", err)
 	}
 
-	// 6. Move tempDir to targetPath (replace if exists)
+	// 5. Move tempDir to targetPath (replace if exists)
 	if err := os.RemoveAll(targetPath); err != nil {
 		return "", fmt.Errorf("failed to remove existing skill at %s: %w", targetPath, err)
 	}
@@ -162,87 +213,3 @@ func installOne(ctx context.Context, st *store.Store, ref, installDir string) (s
 
 	return s.Name, nil
 }
-
-func unpackLayer(r io.Reader, dest string) error {
-	gzr, err := gzip.NewReader(r)
-	if err != nil {
-		return err
-	}
-	defer gzr.Close()
-
-	tr := tar.NewReader(gzr)
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		target := filepath.Join(dest, header.Name)
-
-		if !filepath.IsLocal(header.Name) {
-			return fmt.Errorf("tar archive contains unsafe filename: %s", header.Name)
-		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, 0755); err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(f, tr); err != nil {
-				f.Close()
-				return err
-			}
-			f.Close()
-		}
-	}
-	return nil
-}
-
-func copyDir(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-		targetPath := filepath.Join(dst, relPath)
-
-		if info.IsDir() {
-			return os.MkdirAll(targetPath, info.Mode())
-		}
-
-		return copyFile(path, targetPath, info.Mode())
-	})
-}
-
-func copyFile(src, dst string, mode os.FileMode) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	if _, err := io.Copy(out, in); err != nil {
-		return err
-	}
-
-	return os.Chmod(dst, mode)
-}
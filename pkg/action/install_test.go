@@ -0,0 +1,256 @@
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallSkill_Dependencies(t *testing.T) {
+	t.Setenv(store.SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+
+	build := func(ref, frontmatter string) {
+		srcDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\n"+frontmatter+"---\n# Skill\n"), 0644))
+		_, err := st.Build(ctx, srcDir, ref, nil)
+		require.NoError(t, err)
+	}
+	build("example.com/dep:v1", "name: dep\ndescription: A dependency\n")
+	build("example.com/root:v1", "name: root\ndescription: The root skill\ndependencies:\n  - example.com/dep:v1\n")
+
+	installDir := t.TempDir()
+	name, err := InstallSkill(ctx, st, "example.com/root:v1", installDir)
+	require.NoError(t, err)
+	assert.Equal(t, "root", name)
+
+	assert.FileExists(t, filepath.Join(installDir, "root", "SKILL.md"))
+	assert.FileExists(t, filepath.Join(installDir, "dep", "SKILL.md"))
+}
+
+func TestInstallSkills(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.New(t.TempDir())
+	require.NoError(t, err)
+
+	build := func(ref, frontmatter string) {
+		srcDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\n"+frontmatter+"---\n# Skill\n"), 0644))
+		_, err := st.Build(ctx, srcDir, ref, nil)
+		require.NoError(t, err)
+	}
+	build("example.com/shared:v1", "name: shared\ndescription: A shared dependency\n")
+	build("example.com/one:v1", "name: one\ndescription: The first skill\ndependencies:\n  - example.com/shared:v1\n")
+	build("example.com/two:v1", "name: two\ndescription: The second skill\ndependencies:\n  - example.com/shared:v1\n")
+
+	installDir := t.TempDir()
+	names, err := InstallSkills(ctx, st, []string{"example.com/one:v1", "example.com/two:v1"}, installDir, WithJobs(4))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "shared"}, names)
+	for _, name := range names {
+		assert.FileExists(t, filepath.Join(installDir, name, "SKILL.md"))
+	}
+}
+
+func TestInstallSkill_PinnedDigest(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: pinned\ndescription: A pinned skill\n---\n"), 0644))
+	desc, err := st.Build(ctx, srcDir, "example.com/pinned:1.2.0", nil)
+	require.NoError(t, err)
+
+	// The resolver returns dependencies chosen by a version constraint in this form.
+	installDir := t.TempDir()
+	name, err := InstallSkill(ctx, st, "example.com/pinned:1.2.0@"+desc.Digest.String(), installDir)
+	require.NoError(t, err)
+	assert.Equal(t, "pinned", name)
+	assert.FileExists(t, filepath.Join(installDir, "pinned", "SKILL.md"))
+}
+
+func TestInstallSkill_ExtractionCache(t *testing.T) {
+	t.Setenv(store.SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	storeDir := t.TempDir()
+	st, err := store.New(storeDir)
+	require.NoError(t, err)
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "scripts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: cached\ndescription: A cached skill\n---\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "scripts", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join("scripts", "run.sh"), filepath.Join(srcDir, "run")))
+	desc, err := st.Build(ctx, srcDir, "example.com/cached:v1", nil, store.WithLayered(true))
+	require.NoError(t, err)
+	manifest, err := st.Manifest(ctx, desc)
+	require.NoError(t, err)
+
+	first := filepath.Join(t.TempDir(), "skills")
+	_, err = InstallSkill(ctx, st, "example.com/cached:v1", first)
+	require.NoError(t, err)
+
+	for _, layer := range manifest.Layers {
+		assert.DirExists(t, st.ExtractDir(layer.Digest))
+	}
+
+	// Once extracted, installs no longer read the layers from the store.
+	layer := manifest.Layers[len(manifest.Layers)-1]
+	require.NoError(t, os.Remove(filepath.Join(storeDir, "blobs", "sha256", layer.Digest.Encoded())))
+
+	second := filepath.Join(t.TempDir(), "skills")
+	_, err = InstallSkill(ctx, st, "example.com/cached:v1", second)
+	require.NoError(t, err)
+
+	for _, dir := range []string{first, second} {
+		data, err := os.ReadFile(filepath.Join(dir, "cached", "run"))
+		require.NoError(t, err)
+		assert.Equal(t, "#!/bin/sh\n", string(data))
+
+		// Only a hardlink shares the read-only mode of the cache; reflinks and copies are
+		// writable.
+		info, err := os.Stat(filepath.Join(dir, "cached", "scripts", "run.sh"))
+		require.NoError(t, err)
+		want := os.FileMode(0755)
+		if cachedFile(t, st, manifest, "scripts/run.sh", info) {
+			want = 0555
+		}
+		assert.Equal(t, want, info.Mode().Perm())
+
+		// Skills are staged next to the install dir, not in it.
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temp dirs are left in the install dir")
+		entries, err = os.ReadDir(filepath.Dir(dir))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temp dirs are left next to the install dir")
+	}
+
+	// Prune drops the cached extraction of the deleted layer.
+	_, err = st.Prune(ctx)
+	require.NoError(t, err)
+	assert.NoDirExists(t, st.ExtractDir(layer.Digest))
+	assert.DirExists(t, st.ExtractDir(manifest.Layers[0].Digest))
+}
+
+func TestVerify_CorruptExtraction(t *testing.T) {
+	ctx := context.Background()
+
+	st, err := store.New(t.TempDir())
+	require.NoError(t, err)
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: edited\ndescription: An edited skill\n---\n"), 0644))
+	desc, err := st.Build(ctx, srcDir, "example.com/edited:v1", nil)
+	require.NoError(t, err)
+	manifest, err := st.Manifest(ctx, desc)
+	require.NoError(t, err)
+
+	_, err = InstallSkill(ctx, st, "example.com/edited:v1", t.TempDir())
+	require.NoError(t, err)
+
+	report, err := st.Verify(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Problems)
+
+	// An edit to a hardlinked install has the same effect as one to the cache.
+	layer := manifest.Layers[0].Digest
+	cached := filepath.Join(st.ExtractDir(layer), "SKILL.md")
+	require.NoError(t, os.Chmod(cached, 0644))
+	require.NoError(t, os.WriteFile(cached, []byte("edited"), 0644))
+
+	report, err = st.Verify(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, report.Problems, 1)
+	assert.Equal(t, store.ProblemCorruptExtraction, report.Problems[0].Kind)
+	assert.Equal(t, layer, report.Problems[0].Digest)
+	assert.Equal(t, []digest.Digest{layer}, report.RemovedExtractions)
+	assert.NoDirExists(t, st.ExtractDir(layer))
+	assert.Empty(t, report.RemovedTags, "the artifact itself is intact")
+
+	// The next install extracts the layer again.
+	installDir := t.TempDir()
+	_, err = InstallSkill(ctx, st, "example.com/edited:v1", installDir)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(installDir, "edited", "SKILL.md"))
+	assert.FileExists(t, cached)
+}
+
+// cachedFile reports whether info describes the same file as name in the extraction cache of
+// one of the layers of manifest.
+func cachedFile(t *testing.T, st *store.Store, manifest ocispec.Manifest, name string, info os.FileInfo) bool {
+	t.Helper()
+	for _, layer := range manifest.Layers {
+		cached, err := os.Stat(filepath.Join(st.ExtractDir(layer.Digest), filepath.FromSlash(name)))
+		if err == nil && os.SameFile(info, cached) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExtract_ChainedSymlinksAcrossLayers(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.New(t.TempDir())
+	require.NoError(t, err)
+
+	// The first layer links "b" to the parent of dest through "a"; the second would write
+	// into the directory next to dest if it were installed through that link.
+	push := func(headers []*tar.Header, contents []string) ocispec.Descriptor {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gw)
+		for i, h := range headers {
+			require.NoError(t, tw.WriteHeader(h))
+			if contents[i] != "" {
+				_, err := tw.Write([]byte(contents[i]))
+				require.NoError(t, err)
+			}
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		desc := ocispec.Descriptor{MediaType: store.MediaTypeSkillLayer, Digest: digest.FromBytes(buf.Bytes()), Size: int64(buf.Len())}
+		require.NoError(t, st.Push(ctx, desc, bytes.NewReader(buf.Bytes())))
+		return desc
+	}
+	manifest := ocispec.Manifest{
+		Layers: []ocispec.Descriptor{
+			push([]*tar.Header{
+				{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+				{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "a/.."},
+			}, []string{"", ""}),
+			push([]*tar.Header{
+				{Name: "b/victim/keep", Typeflag: tar.TypeReg, Mode: 0644, Size: 6},
+			}, []string{"pwned\n"}),
+		},
+	}
+
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "dest")
+	victim := filepath.Join(parent, "victim")
+	require.NoError(t, os.Mkdir(victim, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(victim, "keep"), []byte("keep"), 0644))
+
+	require.NoError(t, materialize(ctx, st, manifest, dest))
+
+	data, err := os.ReadFile(filepath.Join(victim, "keep"))
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(data), "the directory next to dest must not be written")
+	_, err = os.Lstat(filepath.Join(dest, "b"))
+	assert.True(t, os.IsNotExist(err), "b resolves outside dest and should have been removed")
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package action
+
+import (
+	"errors"
+	"os"
+)
+
+// reflink is not supported on this platform; files are hardlinked or copied instead.
+func reflink(src, dst string, mode os.FileMode) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,320 @@
+package action
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// unpackLayer extracts a single skill layer with the given media type into dest.
+func unpackLayer(r io.Reader, mediaType, dest string) error {
+	u := &unpacker{dest: dest}
+	if err := u.add(r, mediaType); err != nil {
+		return err
+	}
+	return u.finish()
+}
+
+// unpackManifest fetches the layers of a skill artifact and extracts them, in order, into dest.
+// Both single-layer artifacts and layered artifacts (see store.WithLayered) are supported.
+func unpackManifest(ctx context.Context, st *store.Store, manifest ocispec.Manifest, dest string) error {
+	if len(manifest.Layers) == 0 {
+		return fmt.Errorf("artifact has no layers")
+	}
+	for _, layer := range manifest.Layers {
+		if !store.IsSkillLayer(layer.MediaType) {
+			return fmt.Errorf("unsupported layer media type %q", layer.MediaType)
+		}
+	}
+
+	u := &unpacker{dest: dest}
+	for _, layer := range manifest.Layers {
+		rc, err := st.Fetch(ctx, layer)
+		if err != nil {
+			return fmt.Errorf("failed to fetch layer %s: %w", layer.Digest, err)
+		}
+		err = u.add(rc, layer.MediaType)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to unpack layer %s: %w", layer.Digest, err)
+		}
+	}
+	return u.finish()
+}
+
+// unpacker extracts one or more layers into dest.
+//
+// Symbolic links are created after all layers have been extracted, so that no file is ever
+// written through a link and links may point into later layers. A link is only restored if
+// it resolves inside dest; links that escape are skipped with a warning. Hardlinks must
+// refer to a file that has already been extracted.
+type unpacker struct {
+	dest     string
+	symlinks []*tar.Header
+	dirs     []*tar.Header
+
+	// partial is set when extracting a single layer of a layered artifact, whose links may
+	// point into other layers. Only links that lexically escape dest are skipped; the
+	// resolved targets are checked with checkSymlinks once the artifact is assembled.
+	partial bool
+}
+
+// add extracts a layer, decompressing it according to its media type.
+func (u *unpacker) add(r io.Reader, mediaType string) error {
+	lr, err := store.NewLayerReader(r, mediaType)
+	if err != nil {
+		return err
+	}
+	defer lr.Close()
+
+	tr := tar.NewReader(lr)
+	dest := u.dest
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if !filepath.IsLocal(header.Name) {
+			return fmt.Errorf("tar archive contains unsafe filename: %s", header.Name)
+		}
+
+		target := filepath.Join(dest, header.Name)
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			// Permissions are applied once the directory has been populated.
+			u.dirs = append(u.dirs, header)
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			f.Close()
+			// OpenFile is subject to the umask; make executable bits stick.
+			if err := os.Chmod(target, mode); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			if !filepath.IsLocal(header.Linkname) {
+				return fmt.Errorf("tar archive contains unsafe hardlink: %s -> %s", header.Name, header.Linkname)
+			}
+			source := filepath.Join(dest, header.Linkname)
+			info, err := os.Lstat(source)
+			if err != nil || !info.Mode().IsRegular() {
+				return fmt.Errorf("hardlink %s refers to missing file %s", header.Name, header.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Link(source, target); err != nil {
+				// Fallback: Copy content
+				if err := copyFile(source, target, info.Mode()); err != nil {
+					return err
+				}
+			}
+		case tar.TypeSymlink:
+			u.symlinks = append(u.symlinks, header)
+		default:
+			fmt.Printf("Warning: skipping unsupported entry %s (type %c)\n", header.Name, header.Typeflag)
+		}
+	}
+	return nil
+}
+
+// finish restores symbolic links and applies directory permissions.
+func (u *unpacker) finish() error {
+	names, err := createSymlinks(u.dest, u.symlinks)
+	if err != nil {
+		return err
+	}
+	if !u.partial {
+		if err := checkSymlinks(u.dest, names); err != nil {
+			return err
+		}
+	}
+
+	// Apply directory permissions deepest first, so parents stay writable while children change.
+	for i := len(u.dirs) - 1; i >= 0; i-- {
+		// Skip directories a link has replaced, or that lie below one, as Chmod follows links.
+		name := u.dirs[i].Name
+		target := filepath.Join(u.dest, name)
+		if info, err := os.Lstat(target); err != nil || !info.IsDir() {
+			continue
+		}
+		if parent, err := symlinkParent(u.dest, name); err != nil || parent != "" {
+			continue
+		}
+		mode := os.FileMode(u.dirs[i].Mode).Perm() | 0700
+		if err := os.Chmod(target, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSymlinks creates the given links under dest, skipping any that lexically escape it
+// or would be created through another link, and returns the names of the links created.
+func createSymlinks(dest string, links []*tar.Header) ([]string, error) {
+	var names []string
+	for _, header := range links {
+		target := filepath.Join(dest, header.Name)
+
+		// Reject absolute targets and targets that lexically leave dest.
+		lexical := filepath.Join(filepath.Dir(header.Name), filepath.FromSlash(header.Linkname))
+		if filepath.IsAbs(header.Linkname) || !filepath.IsLocal(lexical) {
+			fmt.Printf("Warning: skipping symlink %s -> %s: points outside the skill directory\n", header.Name, header.Linkname)
+			continue
+		}
+
+		// A link created earlier may redirect a parent of this one outside dest, as in
+		// "a -> .", "b -> a/..", "b/x -> ...", which no lexical check catches.
+		parent, err := symlinkParent(dest, header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if parent != "" {
+			fmt.Printf("Warning: skipping symlink %s -> %s: its parent %s is a symlink\n", header.Name, header.Linkname, filepath.ToSlash(parent))
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return nil, err
+		}
+		if err := os.Symlink(filepath.FromSlash(header.Linkname), target); err != nil {
+			return nil, fmt.Errorf("failed to create symlink %s: %w", header.Name, err)
+		}
+		names = append(names, header.Name)
+	}
+	return names, nil
+}
+
+// symlinkParent returns the first parent directory of name, relative to dest, that is a
+// symbolic link, or "" if there is none. Anything created below such a parent would be
+// written through the link.
+func symlinkParent(dest, name string) (string, error) {
+	dir := filepath.Dir(filepath.FromSlash(name))
+	if dir == "." {
+		return "", nil
+	}
+
+	var parent string
+	for _, part := range strings.Split(dir, string(filepath.Separator)) {
+		parent = filepath.Join(parent, part)
+		info, err := os.Lstat(filepath.Join(dest, parent))
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return parent, nil
+		}
+	}
+	return "", nil
+}
+
+// checkSymlinks removes the named links under dest whose fully resolved target is missing
+// or outside dest. Links may point through other links, so this runs once all exist.
+func checkSymlinks(dest string, names []string) error {
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		target := filepath.Join(dest, name)
+		if _, err := os.Lstat(target); err != nil {
+			continue
+		}
+
+		resolved, err := filepath.EvalSymlinks(target)
+		if err == nil {
+			if rel, relErr := filepath.Rel(root, resolved); relErr != nil || !filepath.IsLocal(rel) {
+				err = fmt.Errorf("points outside the skill directory")
+			}
+		}
+		if err != nil {
+			link, _ := os.Readlink(target)
+			fmt.Printf("Warning: removing symlink %s -> %s: %v\n", filepath.ToSlash(name), filepath.ToSlash(link), err)
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(dst, relPath)
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, targetPath)
+		}
+
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, info.Mode())
+		}
+
+		return copyFile(path, targetPath, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	return os.Chmod(dst, mode)
+}
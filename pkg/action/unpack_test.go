@@ -0,0 +1,196 @@
+package action
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewhowdencom/skr/pkg/store"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildAndUnpack builds srcDir into a fresh store and unpacks its layer into a new directory.
+func buildAndUnpack(t *testing.T, srcDir string, opts ...store.BuildOption) string {
+	t.Helper()
+	ctx := context.Background()
+
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+
+	desc, err := st.Build(ctx, srcDir, "", nil, opts...)
+	require.NoError(t, err)
+
+	rc, err := st.Fetch(ctx, desc)
+	require.NoError(t, err)
+	var manifest ocispec.Manifest
+	require.NoError(t, json.NewDecoder(rc).Decode(&manifest))
+	rc.Close()
+
+	layer, err := st.Fetch(ctx, manifest.Layers[0])
+	require.NoError(t, err)
+	defer layer.Close()
+
+	dest := t.TempDir()
+	require.NoError(t, unpackLayer(layer, manifest.Layers[0].MediaType, dest))
+	return dest
+}
+
+func TestUnpack_Links(t *testing.T) {
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: links\ndescription: test\n---\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "shared"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "shared", "lib.sh"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "scripts"), 0755))
+	require.NoError(t, os.Symlink("../shared/lib.sh", filepath.Join(srcDir, "scripts", "lib.sh")))
+	require.NoError(t, os.Link(filepath.Join(srcDir, "SKILL.md"), filepath.Join(srcDir, "README.md")))
+
+	t.Run("preserve", func(t *testing.T) {
+		dest := buildAndUnpack(t, srcDir)
+
+		link, err := os.Readlink(filepath.Join(dest, "scripts", "lib.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.FromSlash("../shared/lib.sh"), link)
+
+		info, err := os.Stat(filepath.Join(dest, "shared", "lib.sh"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		readme, err := os.ReadFile(filepath.Join(dest, "README.md"))
+		require.NoError(t, err)
+		assert.Contains(t, string(readme), "name: links")
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		dest := buildAndUnpack(t, srcDir, store.WithLinkPolicy(store.LinksFollow))
+
+		info, err := os.Lstat(filepath.Join(dest, "scripts", "lib.sh"))
+		require.NoError(t, err)
+		assert.True(t, info.Mode().IsRegular())
+	})
+
+	t.Run("preserve rejects escaping links", func(t *testing.T) {
+		outside := t.TempDir()
+		escaping := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(escaping, "SKILL.md"), []byte("---\nname: x\ndescription: x\n---\n"), 0644))
+		require.NoError(t, os.Symlink(outside, filepath.Join(escaping, "outside")))
+
+		st, err := store.NewMemory()
+		require.NoError(t, err)
+		_, err = st.Build(context.Background(), escaping, "", nil)
+		assert.ErrorContains(t, err, "outside the skill directory")
+	})
+}
+
+func TestUnpack_SkipsEscapingSymlinks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, h := range []*tar.Header{
+		{Name: "self", Typeflag: tar.TypeSymlink, Linkname: "."},
+		{Name: "absolute", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		{Name: "parent", Typeflag: tar.TypeSymlink, Linkname: "../outside"},
+		{Name: "chained", Typeflag: tar.TypeSymlink, Linkname: "self/.."},
+	} {
+		require.NoError(t, tw.WriteHeader(h))
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	dest := t.TempDir()
+	require.NoError(t, unpackLayer(io.Reader(buf), store.MediaTypeSkillLayer, dest))
+
+	_, err := os.Lstat(filepath.Join(dest, "self"))
+	assert.NoError(t, err)
+	for _, name := range []string{"absolute", "parent", "chained"} {
+		_, err := os.Lstat(filepath.Join(dest, name))
+		assert.True(t, os.IsNotExist(err), "%s should not have been restored", name)
+	}
+}
+
+func TestUnpack_ChainedSymlinks(t *testing.T) {
+	// "b" resolves to the parent of dest through "a", so "b/victim" would replace the
+	// victim directory next to dest if links were created through earlier links.
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "dest")
+	require.NoError(t, os.Mkdir(dest, 0755))
+	victim := filepath.Join(parent, "victim")
+	require.NoError(t, os.Mkdir(victim, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(victim, "keep"), []byte("keep"), 0644))
+
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, h := range []*tar.Header{
+		{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "."},
+		{Name: "b", Typeflag: tar.TypeSymlink, Linkname: "a/.."},
+		{Name: "b/victim", Typeflag: tar.TypeSymlink, Linkname: "a"},
+		{Name: "a/b/c", Typeflag: tar.TypeSymlink, Linkname: "."},
+	} {
+		require.NoError(t, tw.WriteHeader(h))
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	require.NoError(t, unpackLayer(io.Reader(buf), store.MediaTypeSkillLayer, dest))
+
+	info, err := os.Lstat(victim)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "the directory next to dest must not be replaced")
+	assert.FileExists(t, filepath.Join(victim, "keep"))
+	_, err = os.Lstat(filepath.Join(dest, "b"))
+	assert.True(t, os.IsNotExist(err), "b resolves outside dest and should have been removed")
+}
+
+func TestUnpackManifest_Layered(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "scripts"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "assets"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: layered\ndescription: test\n---\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "scripts", "run.sh"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "assets", "logo.svg"), []byte("<svg/>"), 0644))
+	// A link from the first layer into a later one
+	require.NoError(t, os.Symlink(filepath.Join("scripts", "run.sh"), filepath.Join(srcDir, "run")))
+
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+	desc, err := st.Build(ctx, srcDir, "", nil, store.WithLayered(true))
+	require.NoError(t, err)
+	manifest, err := st.Manifest(ctx, desc)
+	require.NoError(t, err)
+	require.Len(t, manifest.Layers, 3)
+
+	dest := t.TempDir()
+	require.NoError(t, unpackManifest(ctx, st, manifest, dest))
+
+	data, err := os.ReadFile(filepath.Join(dest, "run"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(data))
+	assert.FileExists(t, filepath.Join(dest, "SKILL.md"))
+	assert.FileExists(t, filepath.Join(dest, "assets", "logo.svg"))
+
+	manifest.Layers[1].MediaType = "application/vnd.oci.image.layer.v1.tar"
+	assert.Error(t, unpackManifest(ctx, st, manifest, t.TempDir()))
+}
+
+func TestUnpack_Compression(t *testing.T) {
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: compressed\ndescription: test\n---\n"), 0644))
+
+	for _, c := range []store.Compression{store.CompressionGzip, store.CompressionZstd, store.CompressionNone} {
+		t.Run(string(c), func(t *testing.T) {
+			dest := buildAndUnpack(t, srcDir, store.WithCompression(c))
+			data, err := os.ReadFile(filepath.Join(dest, "SKILL.md"))
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "name: compressed")
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/andrewhowdencom/skr/pkg/skill"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,6 +24,8 @@ var KnownAgents = map[string]func(home string) string{
 type Config struct {
 	Agents []string `yaml:"agents"`
 	Skills []string `yaml:"skills"`
+	// Budgets limits the estimated token counts of skills checked by validate and build.
+	Budgets skill.TokenBudgets `yaml:"budgets,omitempty"`
 }
 
 func (c *Config) Merge(other *Config) {
@@ -30,6 +33,8 @@ func (c *Config) Merge(other *Config) {
 		return
 	}
 
+	c.Budgets = c.Budgets.Merge(other.Budgets)
+
 	// Merge skills (append unique?)
 	c.Skills = append(c.Skills, other.Skills...)
 
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/andrewhowdencom/skr/pkg/skill"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -28,8 +29,9 @@ func TestLoadMerged(t *testing.T) {
 
 	// 1. Create Global Config (XDG)
 	globalCfg := Config{
-		Skills: []string{"global-skill"},
-		Agents: []string{"antigravity"},
+		Skills:  []string{"global-skill"},
+		Agents:  []string{"antigravity"},
+		Budgets: skill.TokenBudgets{Body: skill.Budget{Warn: 3000, Fail: 6000}},
 	}
 	globalData, err := yaml.Marshal(globalCfg)
 	require.NoError(t, err)
@@ -43,8 +45,9 @@ func TestLoadMerged(t *testing.T) {
 
 	// 2. Create Local Config in ROOT (Parent of project)
 	localCfg := Config{
-		Skills: []string{"local-skill"},
-		Agents: []string{"roocode"},
+		Skills:  []string{"local-skill"},
+		Agents:  []string{"roocode"},
+		Budgets: skill.TokenBudgets{Body: skill.Budget{Fail: 4000}},
 	}
 	localData, err := yaml.Marshal(localCfg)
 	require.NoError(t, err)
@@ -63,4 +66,7 @@ func TestLoadMerged(t *testing.T) {
 	assert.Contains(t, cfg.Agents, "antigravity")
 	assert.Contains(t, cfg.Agents, "roocode")
 	assert.Equal(t, 2, len(cfg.Agents))
+
+	// Verify Budgets (local limits override global ones)
+	assert.Equal(t, skill.Budget{Warn: 3000, Fail: 4000}, cfg.Budgets.Body)
 }
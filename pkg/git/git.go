@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // GetShortSHA returns the short SHA of the current HEAD
@@ -57,3 +59,65 @@ func ChangedFiles(baseRef string) ([]string, error) {
 	}
 	return files, nil
 }
+
+// LastCommitTime returns the committer time of the most recent commit touching path
+func LastCommitTime(path string) (time.Time, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%ct", "--", ".")
+	cmd.Dir = path
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return time.Time{}, err
+	}
+
+	value := strings.TrimSpace(out.String())
+	if value == "" {
+		return time.Time{}, fmt.Errorf("no commits found for %s", path)
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid commit time %q: %w", value, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// RemoteURL returns the URL of the origin remote of the repository containing path.
+// GitHub SSH URLs are converted to their HTTPS form so that they can be used as a source link.
+func RemoteURL(path string) (string, error) {
+	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
+	cmd.Dir = path
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSpace(out.String())
+	// git@github.com:user/repo.git -> https://github.com/user/repo
+	if strings.HasPrefix(url, "git@github.com:") {
+		url = strings.Replace(url, "git@github.com:", "https://github.com/", 1)
+		url = strings.TrimSuffix(url, ".git")
+	} else if strings.HasPrefix(url, "https://github.com/") {
+		url = strings.TrimSuffix(url, ".git")
+	}
+	return url, nil
+}
+
+// Revision returns the full SHA of the most recent commit touching path, so that it does not
+// change with commits elsewhere in the repository
+func Revision(path string) (string, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%H", "--", ".")
+	cmd.Dir = path
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	revision := strings.TrimSpace(out.String())
+	if revision == "" {
+		return "", fmt.Errorf("no commits found for %s", path)
+	}
+	return revision, nil
+}
@@ -0,0 +1,183 @@
+// Package ignore implements gitignore-style exclusion rules for packaging skills.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// FileName is the name of the ignore file read from the root of a skill directory.
+	FileName = ".skrignore"
+)
+
+// DefaultPatterns are always applied before the patterns in .skrignore.
+// They can be re-included with a negated pattern (e.g. "!.DS_Store").
+var DefaultPatterns = []string{
+	".git/",
+	".hg/",
+	".svn/",
+	"node_modules/",
+	".DS_Store",
+	"Thumbs.db",
+	"*.swp",
+	"*.swo",
+	"*~",
+	FileName,
+}
+
+type rule struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher decides whether a path relative to the skill root is excluded.
+type Matcher struct {
+	rules []rule
+}
+
+// New compiles the given gitignore-style patterns into a Matcher.
+func New(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, p := range patterns {
+		if err := m.add(p); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Load returns a Matcher for dir combining DefaultPatterns with the contents of dir/.skrignore, if present.
+func Load(dir string) (*Matcher, error) {
+	patterns := append([]string{}, DefaultPatterns...)
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+
+	m, err := New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	return m, nil
+}
+
+// Match reports whether relPath (slash or OS separated) is excluded.
+// The last matching rule wins, as in gitignore.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	excluded := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(relPath) {
+			excluded = !r.negate
+		}
+	}
+	return excluded
+}
+
+func (m *Matcher) add(line string) error {
+	line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	r := rule{pattern: line}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// Escaped leading "#" or "!"
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// A pattern containing a slash (other than a trailing one) is anchored to the root.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := translate(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.pattern, err)
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+	return nil
+}
+
+// translate converts a gitignore glob into a regular expression fragment.
+func translate(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"basename at any depth", []string{"*.log"}, "logs/debug.log", false, true},
+		{"basename no match", []string{"*.log"}, "logs/debug.txt", false, false},
+		{"dir only matches dir", []string{"fixtures/"}, "tests/fixtures", true, true},
+		{"dir only skips file", []string{"fixtures/"}, "fixtures", false, false},
+		{"anchored", []string{"/build"}, "build", true, true},
+		{"anchored not nested", []string{"/build"}, "src/build", true, false},
+		{"middle slash anchors", []string{"docs/*.md"}, "docs/a.md", false, true},
+		{"middle slash anchors nested", []string{"docs/*.md"}, "x/docs/a.md", false, false},
+		{"double star prefix", []string{"**/tmp"}, "a/b/tmp", true, true},
+		{"double star suffix", []string{"assets/**"}, "assets/img/a.png", false, true},
+		{"double star middle", []string{"a/**/z"}, "a/b/c/z", false, true},
+		{"double star middle zero dirs", []string{"a/**/z"}, "a/z", false, true},
+		{"negation", []string{"*.md", "!SKILL.md"}, "SKILL.md", false, false},
+		{"last rule wins", []string{"!SKILL.md", "*.md"}, "SKILL.md", false, true},
+		{"character class", []string{"file[0-9].txt"}, "file3.txt", false, true},
+		{"negated class", []string{"file[!0-9].txt"}, "file3.txt", false, false},
+		{"comment", []string{"# *.md"}, "a.md", false, false},
+		{"escaped hash", []string{`\#notes`}, "#notes", false, true},
+		{"trailing whitespace", []string{"*.tmp   "}, "a.tmp", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.patterns)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.Match(tt.path, tt.isDir))
+		})
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("tests/\n!.DS_Store\n"), 0644))
+
+	m, err := Load(dir)
+	require.NoError(t, err)
+
+	assert.True(t, m.Match(".git", true))
+	assert.True(t, m.Match("scripts/node_modules", true))
+	assert.True(t, m.Match(".SKILL.md.swp", false))
+	assert.True(t, m.Match(FileName, false))
+	assert.True(t, m.Match("tests", true))
+	assert.False(t, m.Match(".DS_Store", false), "defaults can be re-included")
+	assert.False(t, m.Match("SKILL.md", false))
+}
@@ -0,0 +1,59 @@
+// Package parallel runs tasks with bounded concurrency.
+package parallel
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultJobs is the default number of tasks run at once.
+var DefaultJobs = runtime.NumCPU()
+
+// ForEach calls fn for each index from 0 to n-1, running up to jobs calls at once. Calls
+// start in index order, and none start once one has failed or ctx is done.
+//
+// It returns the error of the lowest index that failed, so that the result does not depend
+// on the order in which the calls finish.
+func ForEach(ctx context.Context, jobs, n int, fn func(ctx context.Context, i int) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	errs := make([]error, n)
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+
+start:
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break start
+		}
+		if failed.Load() || ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				errs[i] = err
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,76 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEach(t *testing.T) {
+	var running, peak atomic.Int32
+	results := make([]int, 20)
+	err := ForEach(context.Background(), 3, len(results), func(ctx context.Context, i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		results[i] = i * i
+		return nil
+	})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	for i, r := range results {
+		assert.Equal(t, i*i, r)
+	}
+}
+
+func TestForEach_LowestError(t *testing.T) {
+	err := ForEach(context.Background(), 4, 8, func(ctx context.Context, i int) error {
+		switch i {
+		case 1:
+			// Fail after a later index has failed.
+			time.Sleep(20 * time.Millisecond)
+			return fmt.Errorf("task %d failed", i)
+		case 2, 3:
+			return fmt.Errorf("task %d failed", i)
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "task 1 failed")
+}
+
+func TestForEach_StopsAfterFailure(t *testing.T) {
+	var started atomic.Int32
+	err := ForEach(context.Background(), 1, 10, func(ctx context.Context, i int) error {
+		started.Add(1)
+		if i == 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, int32(3), started.Load())
+}
+
+func TestForEach_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := ForEach(ctx, 2, 5, func(ctx context.Context, i int) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
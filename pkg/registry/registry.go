@@ -7,8 +7,10 @@ import (
 
 	skrauth "github.com/andrewhowdencom/skr/pkg/auth"
 	"github.com/andrewhowdencom/skr/pkg/store"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"oras.land/oras-go/v2"
+	orasregistry "oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/credentials"
@@ -91,10 +93,81 @@ func Pull(ctx context.Context, st *store.Store, ref string) error {
 		srcRef = "latest"
 	}
 
-	_, err = oras.Copy(ctx, repo, srcRef, st, ref, oras.DefaultCopyOptions)
+	// A reference pinned to a digest is stored under its tag, if it has one, or its digest.
+	dstRef := ref
+	if parsed, err := store.ParseReference(ref); err == nil && parsed.Digest != "" {
+		dstRef = parsed.Digest.String()
+		if parsed.Tag != "" {
+			dstRef = store.Reference{Repository: parsed.Repository, Tag: parsed.Tag}.String()
+		}
+	}
+
+	desc, err := repo.Resolve(ctx, srcRef)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+
+	// Download the blobs under the shared store lock, so that other processes can keep
+	// using the store, and a prune cannot remove the blobs while they are being copied.
+	// The manifest itself is written to the index, which needs the exclusive lock.
+	unlock, err := st.RLock(ctx)
+	if err != nil {
+		return err
+	}
+	opts := oras.DefaultCopyGraphOptions
+	opts.PreCopy = func(ctx context.Context, node ocispec.Descriptor) error {
+		if node.Digest == desc.Digest {
+			return oras.SkipNode
+		}
+		return nil
+	}
+	err = oras.CopyGraph(ctx, repo, st, desc, opts)
+	unlock()
 	if err != nil {
 		return fmt.Errorf("failed to pull %s: %w", ref, err)
 	}
 
+	// Push and tag the manifest under the exclusive lock, which cannot be taken while holding
+	// the shared one. A prune may have removed the untagged blobs in between; copying the
+	// graph again restores them, and only checks that they exist otherwise.
+	unlock, err = st.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := oras.CopyGraph(ctx, repo, st, desc, oras.DefaultCopyGraphOptions); err != nil {
+		return fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+	if err := st.Tag(ctx, desc, dstRef); err != nil {
+		return fmt.Errorf("failed to tag %s: %w", ref, err)
+	}
+
 	return nil
 }
+
+// Tags lists the tags of a repository in a remote registry.
+func Tags(ctx context.Context, repository string) ([]string, error) {
+	repo, err := remote.NewRepository(repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository %s: %w", repository, err)
+	}
+
+	baseTransport := otelhttp.NewTransport(http.DefaultTransport)
+	retryTransport := retry.NewTransport(baseTransport)
+	httpClient := &http.Client{
+		Transport: retryTransport,
+	}
+
+	repo.Client = &auth.Client{
+		Client:     httpClient,
+		Cache:      auth.DefaultCache,
+		Credential: credentials.Credential(skrauth.NewStore()),
+	}
+
+	tags, err := orasregistry.Tags(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", repository, err)
+	}
+	return tags, nil
+}
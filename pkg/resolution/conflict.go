@@ -0,0 +1,194 @@
+package resolution
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictPolicy decides which artifact is installed when different artifacts of the same
+// repository, or with the same skill name, are required.
+type ConflictPolicy string
+
+const (
+	// PolicyFail fails the resolution on any conflict.
+	PolicyFail ConflictPolicy = "fail"
+	// PolicyHighest installs the artifact with the highest semantic version tag.
+	PolicyHighest ConflictPolicy = "highest"
+	// PolicyRoot installs the artifact nearest to the root, so that the skill being
+	// installed, and then its direct dependencies, decide.
+	PolicyRoot ConflictPolicy = "root"
+)
+
+// ConflictPolicies lists the supported conflict policies.
+var ConflictPolicies = []ConflictPolicy{PolicyFail, PolicyHighest, PolicyRoot}
+
+// ParseConflictPolicy parses the name of a conflict policy.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	for _, p := range ConflictPolicies {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid conflict policy %q: must be fail, highest or root", s)
+}
+
+// Kinds of conflicts.
+const (
+	ConflictRepository = "repository"
+	ConflictName       = "skill name"
+)
+
+// Conflict is a set of different artifacts that would be installed into the same directory.
+type Conflict struct {
+	// Kind is ConflictRepository or ConflictName.
+	Kind string
+	// Key is the repository or skill name the candidates share.
+	Key string
+	// Candidates are the conflicting artifacts, nearest to the root first.
+	Candidates []*Node
+	// Chosen is the candidate the policy chose, if it resolved the conflict.
+	Chosen *Node
+	Policy ConflictPolicy
+}
+
+func (c Conflict) String() string {
+	required := make([]string, len(c.Candidates))
+	for i, n := range c.Candidates {
+		required[i] = fmt.Sprintf("%s (by %s)", n.Ref, n.requiredBy())
+	}
+	s := fmt.Sprintf("%s %s is required as %s", c.Kind, c.Key, strings.Join(required, " and "))
+
+	switch {
+	case c.Chosen == nil:
+		return s
+	case c.Policy == PolicyHighest:
+		return fmt.Sprintf("%s; using %s, the highest version", s, c.Chosen.Ref)
+	}
+	return fmt.Sprintf("%s; using %s, the nearest to the root", s, c.Chosen.Ref)
+}
+
+// ConflictError is returned when the conflict policy is PolicyFail and there are conflicts.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	lines := []string{"conflicting dependencies:"}
+	for _, c := range e.Conflicts {
+		lines = append(lines, "  - "+c.String())
+	}
+	lines = append(lines, "set the conflict policy to highest or root to choose one")
+	return strings.Join(lines, "\n")
+}
+
+// resolveConflicts finds the conflicts in g, first between artifacts of the same repository
+// and then between skills of the same name, and resolves them with the conflict policy. It
+// returns the references replaced by the chosen ones.
+func (r *Resolver) resolveConflicts(g *Graph) (map[string]string, error) {
+	policy := r.policy
+	if policy == "" {
+		policy = PolicyFail
+	}
+
+	replaced := make(map[string]string)
+	var failed []Conflict
+	groupings := []struct {
+		kind string
+		key  func(*Node) string
+	}{
+		{ConflictRepository, func(n *Node) string { return n.Repository }},
+		{ConflictName, func(n *Node) string { return n.Name }},
+	}
+	for _, grouping := range groupings {
+		for _, c := range g.conflicts(grouping.kind, grouping.key, replaced) {
+			if policy == PolicyFail {
+				failed = append(failed, c)
+				continue
+			}
+
+			chosen, err := choose(policy, c)
+			if err != nil {
+				return nil, err
+			}
+			c.Chosen, c.Policy = chosen, policy
+			for _, n := range c.Candidates {
+				if n != chosen {
+					replaced[n.Ref] = chosen.Ref
+				}
+			}
+			if r.reporter != nil {
+				r.reporter(c)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return nil, &ConflictError{Conflicts: failed}
+	}
+	return replaced, nil
+}
+
+// conflicts groups the nodes that have not been replaced by key, and returns the groups
+// with more than one artifact. References to the same digest are not a conflict; all but
+// the first are recorded in replaced.
+func (g *Graph) conflicts(kind string, key func(*Node) string, replaced map[string]string) []Conflict {
+	groups := make(map[string][]*Node)
+	var keys []string
+	for _, ref := range g.Order {
+		if _, ok := replaced[ref]; ok {
+			continue
+		}
+		n := g.Nodes[ref]
+		k := key(n)
+		if k == "" {
+			continue
+		}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], n)
+	}
+
+	var conflicts []Conflict
+	for _, k := range keys {
+		var candidates []*Node
+	nodes:
+		for _, n := range groups[k] {
+			for _, c := range candidates {
+				if c.Digest == n.Digest {
+					replaced[n.Ref] = c.Ref
+					continue nodes
+				}
+				// A conflict within a repository has been reported already.
+				if kind == ConflictName && c.Repository == n.Repository {
+					continue nodes
+				}
+			}
+			candidates = append(candidates, n)
+		}
+		if len(candidates) > 1 {
+			conflicts = append(conflicts, Conflict{Kind: kind, Key: k, Candidates: candidates})
+		}
+	}
+	return conflicts
+}
+
+// choose returns the candidate of c that policy installs.
+func choose(policy ConflictPolicy, c Conflict) (*Node, error) {
+	if policy != PolicyHighest {
+		return c.Candidates[0], nil
+	}
+
+	var chosen *Node
+	var highest Version
+	for _, n := range c.Candidates {
+		v, err := ParseVersion(n.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("cannot choose the highest version of %s %s: %s is not tagged with a semantic version", c.Kind, c.Key, n.Ref)
+		}
+		if chosen == nil || v.Compare(highest) > 0 {
+			chosen, highest = n, v
+		}
+	}
+	return chosen, nil
+}
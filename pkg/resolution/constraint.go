@@ -0,0 +1,324 @@
+package resolution
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// versionRegex matches a semantic version tag, with an optional "v" prefix.
+	versionRegex = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+	// partialRegex matches the version of a comparator, in which trailing parts may be
+	// missing or wildcards.
+	partialRegex = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+	// operatorRegex splits the operator from the version of a comparator.
+	operatorRegex = regexp.MustCompile(`^(\^|~|>=|<=|>|<|=)?\s*(.*)$`)
+)
+
+// Version is a semantic version, as used in the tags of skill artifacts.
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string
+}
+
+// ParseVersion parses a semantic version such as "1.2.3" or "v1.2.3-rc.1". Build metadata
+// is accepted and ignored.
+func ParseVersion(s string) (Version, error) {
+	m := versionRegex.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q", s)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return Version{Major: major, Minor: minor, Patch: patch, Prerelease: m[4]}, nil
+}
+
+// String formats the version without a "v" prefix.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 as v is lower than, equal to or higher than other, following
+// the precedence rules of semantic versioning.
+func (v Version) Compare(other Version) int {
+	for _, c := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if c[0] != c[1] {
+			return compareInts(c[0], c[1])
+		}
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// comparePrerelease compares pre-release identifiers. A version without one is higher.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return compareInts(an, bn)
+			}
+		case aErr == nil:
+			// Numeric identifiers are lower than alphanumeric ones.
+			return -1
+		case bErr == nil:
+			return 1
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return compareInts(len(as), len(bs))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparator is a single comparison against a version, such as ">=1.2.0".
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// Constraint is a semantic version range, such as "^1.2" or ">=1.2.0 <2.0.0".
+//
+// Comparators separated by spaces or commas must all match, and sets of them may be
+// combined with "||". Besides =, <, <=, > and >=, the following are supported:
+//   - ^1.2.3 allows changes that do not modify the left-most non-zero part: >=1.2.3 <2.0.0.
+//   - ~1.2.3 allows patch changes: >=1.2.3 <1.3.0.
+//   - 1.2, 1.2.x and 1.2.* allow any version with the given prefix; * allows any version.
+//
+// Pre-releases only match a constraint that names a pre-release of the same version, so
+// that ^1.2 never selects 1.3.0-rc.1.
+type Constraint struct {
+	raw  string
+	sets [][]comparator
+}
+
+// ParseConstraint parses a semantic version constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+	for _, set := range strings.Split(s, "||") {
+		comparators, err := parseComparatorSet(set)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		c.sets = append(c.sets, comparators)
+	}
+	return c, nil
+}
+
+// parseComparatorSet parses comparators that must all match.
+func parseComparatorSet(s string) ([]comparator, error) {
+	fields := strings.Fields(strings.ReplaceAll(s, ",", " "))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version range")
+	}
+
+	var comparators []comparator
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		// Allow a space between the operator and the version, as in ">= 1.2".
+		if operatorRegex.FindStringSubmatch(field)[2] == "" && i+1 < len(fields) {
+			i++
+			field += fields[i]
+		}
+		expanded, err := parseComparator(field)
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, expanded...)
+	}
+	return comparators, nil
+}
+
+// parseComparator expands a comparator, which may use a shorthand such as ^1.2, into
+// plain comparisons.
+func parseComparator(s string) ([]comparator, error) {
+	m := operatorRegex.FindStringSubmatch(s)
+	op, rest := m[1], m[2]
+	p := partialRegex.FindStringSubmatch(rest)
+	if p == nil {
+		return nil, fmt.Errorf("invalid version %q", rest)
+	}
+
+	// parts counts the leading numeric parts; the rest are missing or wildcards.
+	var nums [3]int
+	parts := 0
+	for i := 1; i <= 3; i++ {
+		if p[i] == "" || strings.ContainsAny(p[i], "xX*") {
+			break
+		}
+		nums[i-1], _ = strconv.Atoi(p[i])
+		parts++
+	}
+	if p[4] != "" && parts < 3 {
+		return nil, fmt.Errorf("invalid version %q: a pre-release needs a full version", rest)
+	}
+	lower := Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Prerelease: p[4]}
+
+	// next returns the lowest version above every version with the first n parts of lower.
+	next := func(n int) Version {
+		switch n {
+		case 1:
+			return Version{Major: lower.Major + 1}
+		case 2:
+			return Version{Major: lower.Major, Minor: lower.Minor + 1}
+		}
+		return Version{Major: lower.Major, Minor: lower.Minor, Patch: lower.Patch + 1}
+	}
+	between := func(upper Version) []comparator {
+		return []comparator{{">=", lower}, {"<", upper}}
+	}
+
+	if parts == 0 {
+		if op == "<" || op == ">" {
+			// Nothing is below or above every version.
+			return []comparator{{"<", Version{}}}, nil
+		}
+		return []comparator{{">=", Version{}}}, nil
+	}
+
+	switch op {
+	case "^":
+		switch {
+		case lower.Major > 0 || parts == 1:
+			return between(next(1)), nil
+		case lower.Minor > 0 || parts == 2:
+			return between(next(2)), nil
+		}
+		return between(next(3)), nil
+	case "~":
+		if parts == 1 {
+			return between(next(1)), nil
+		}
+		return between(next(2)), nil
+	case ">":
+		if parts < 3 {
+			return []comparator{{">=", next(parts)}}, nil
+		}
+		return []comparator{{">", lower}}, nil
+	case ">=", "<":
+		return []comparator{{op, lower}}, nil
+	case "<=":
+		if parts < 3 {
+			return []comparator{{"<", next(parts)}}, nil
+		}
+		return []comparator{{"<=", lower}}, nil
+	}
+	if parts < 3 {
+		return between(next(parts)), nil
+	}
+	return []comparator{{"=", lower}}, nil
+}
+
+// String returns the constraint as it was written.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether v satisfies the constraint.
+func (c *Constraint) Check(v Version) bool {
+	for _, set := range c.sets {
+		if matchesSet(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSet(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if v.Prerelease == "" {
+		return true
+	}
+	for _, c := range set {
+		p := c.version
+		if p.Prerelease != "" && p.Major == v.Major && p.Minor == v.Minor && p.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// Highest returns the tag with the highest semantic version that satisfies the constraint.
+// Tags that are not semantic versions are ignored.
+func (c *Constraint) Highest(tags []string) (string, bool) {
+	var best string
+	var bestVersion Version
+	for _, tag := range tags {
+		v, err := ParseVersion(tag)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		// Prefer the tag without a "v" prefix when both exist, so the choice is stable.
+		if best == "" || v.Compare(bestVersion) > 0 || (v.Compare(bestVersion) == 0 && tag < best) {
+			best, bestVersion = tag, v
+		}
+	}
+	return best, best != ""
+}
+
+// SplitConstraint splits a dependency of the form repository@constraint, such as
+// ghcr.io/org/git@^1.2, into the repository and the constraint. The constraint is nil for
+// plain references, including those pinned to a digest.
+func SplitConstraint(ref string) (string, *Constraint, error) {
+	idx := strings.LastIndex(ref, "@")
+	// Digests contain a colon; constraints never do.
+	if idx == -1 || strings.Contains(ref[idx+1:], ":") {
+		return ref, nil, nil
+	}
+
+	repository := ref[:idx]
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		return "", nil, fmt.Errorf("invalid dependency %q: a version constraint cannot be combined with a tag", ref)
+	}
+	constraint, err := ParseConstraint(ref[idx+1:])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid dependency %q: %w", ref, err)
+	}
+	return repository, constraint, nil
+}
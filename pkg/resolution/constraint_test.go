@@ -0,0 +1,102 @@
+package resolution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion_Compare(t *testing.T) {
+	ordered := []string{"0.9.0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "v1.10.0", "2.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		lower, err := ParseVersion(ordered[i-1])
+		require.NoError(t, err)
+		higher, err := ParseVersion(ordered[i])
+		require.NoError(t, err)
+		assert.Equal(t, -1, lower.Compare(higher), "%s < %s", lower, higher)
+		assert.Equal(t, 1, higher.Compare(lower), "%s > %s", higher, lower)
+	}
+
+	_, err := ParseVersion("latest")
+	assert.Error(t, err)
+	_, err = ParseVersion("1.2")
+	assert.Error(t, err)
+}
+
+func TestConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{"^1.2", []string{"1.2.0", "1.9.3"}, []string{"1.1.9", "2.0.0", "1.3.0-rc.1"}},
+		{"^1.2.3", []string{"1.2.3", "1.4.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1", []string{"1.0.0", "1.9.9"}, []string{"2.0.0"}},
+		{"1.2.x", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"*", []string{"0.0.1", "3.0.0"}, []string{"1.0.0-rc.1"}},
+		{">=1.2 <2", []string{"1.2.0", "1.99.0"}, []string{"1.1.0", "2.0.0"}},
+		{">= 1.2, < 1.4", []string{"1.3.5"}, []string{"1.4.0"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"^1.0 || ^3.0", []string{"1.5.0", "3.1.0"}, []string{"2.0.0"}},
+		{"^2.0.0-rc.1", []string{"2.0.0-rc.2", "2.0.0", "2.1.0"}, []string{"2.1.0-rc.1", "3.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			require.NoError(t, err)
+			for _, s := range tt.match {
+				v, err := ParseVersion(s)
+				require.NoError(t, err)
+				assert.True(t, c.Check(v), "%s should satisfy %s", s, tt.constraint)
+			}
+			for _, s := range tt.noMatch {
+				v, err := ParseVersion(s)
+				require.NoError(t, err)
+				assert.False(t, c.Check(v), "%s should not satisfy %s", s, tt.constraint)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "^", "abc", "^1.2 ||", "1.x-rc.1"} {
+		_, err := ParseConstraint(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestConstraint_Highest(t *testing.T) {
+	c, err := ParseConstraint("^1.2")
+	require.NoError(t, err)
+
+	tag, ok := c.Highest([]string{"latest", "1.1.0", "v1.2.0", "1.4.1", "1.10.0", "2.0.0", "1.11.0-rc.1"})
+	assert.True(t, ok)
+	assert.Equal(t, "1.10.0", tag)
+
+	_, ok = c.Highest([]string{"latest", "2.0.0"})
+	assert.False(t, ok)
+}
+
+func TestSplitConstraint(t *testing.T) {
+	repository, c, err := SplitConstraint("ghcr.io/org/git@^1.2")
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/org/git", repository)
+	require.NotNil(t, c)
+	assert.Equal(t, "^1.2", c.String())
+
+	for _, plain := range []string{"ghcr.io/org/git:v1", "localhost:5000/git", "ghcr.io/org/git@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"} {
+		repository, c, err := SplitConstraint(plain)
+		require.NoError(t, err)
+		assert.Equal(t, plain, repository)
+		assert.Nil(t, c)
+	}
+
+	_, _, err = SplitConstraint("ghcr.io/org/git:v1@^1.2")
+	assert.Error(t, err)
+	_, _, err = SplitConstraint("ghcr.io/org/git@latest")
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package resolution
+
+import (
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// Node is an artifact in a dependency graph.
+type Node struct {
+	// Ref is the resolved reference; dependencies chosen by a version constraint are
+	// pinned to a digest.
+	Ref        string
+	Repository string
+	Tag        string
+	Digest     digest.Digest
+	// Name is the skill name, which is empty for artifacts without a skill config.
+	Name string
+	// Dependencies are the resolved references of the dependencies, in the order declared.
+	Dependencies []string
+	// Path is the shortest chain of references from the root to this node.
+	Path []string
+
+	// requires are the dependencies as declared.
+	requires []string
+}
+
+// requiredBy describes the chain of dependents through which the node was first reached.
+func (n *Node) requiredBy() string {
+	if len(n.Path) <= 1 {
+		return "the root"
+	}
+	return strings.Join(n.Path[:len(n.Path)-1], " -> ")
+}
+
+// Graph is the dependency graph of one or more skills.
+type Graph struct {
+	Roots []*Node
+	Nodes map[string]*Node
+	// Order lists the references of the nodes in breadth-first order from the root.
+	Order []string
+}
+
+func (g *Graph) add(n *Node) {
+	g.Nodes[n.Ref] = n
+	g.Order = append(g.Order, n.Ref)
+}
+
+// Cycle returns a dependency cycle as the chain of references from a root to the first
+// artifact that depends on itself, ending with that artifact again, or nil if there is none.
+func (g *Graph) Cycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var stack []string
+
+	var visit func(ref string) []string
+	visit = func(ref string) []string {
+		state[ref] = visiting
+		stack = append(stack, ref)
+		for _, dep := range g.Nodes[ref].Dependencies {
+			switch state[dep] {
+			case visiting:
+				return append(append([]string(nil), stack...), dep)
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[ref] = done
+		return nil
+	}
+	for _, root := range g.Roots {
+		if state[root.Ref] == unvisited {
+			if cycle := visit(root.Ref); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// install returns the references to install, in breadth-first order from the roots, with
+// each replaced reference substituted by its replacement.
+func (g *Graph) install(replaced map[string]string) []string {
+	substitute := func(ref string) string {
+		// A reference chosen for its repository may be replaced again for its skill name.
+		for {
+			r, ok := replaced[ref]
+			if !ok {
+				return ref
+			}
+			ref = r
+		}
+	}
+
+	var queue []string
+	visited := make(map[string]bool)
+	for _, root := range g.Roots {
+		ref := substitute(root.Ref)
+		if !visited[ref] {
+			visited[ref] = true
+			queue = append(queue, ref)
+		}
+	}
+	var refs []string
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		refs = append(refs, ref)
+		for _, dep := range g.Nodes[ref].Dependencies {
+			dep = substitute(dep)
+			if !visited[dep] {
+				visited[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return refs
+}
+
+// CycleError is returned when the dependencies of a skill form a cycle.
+type CycleError struct {
+	// Path is the chain of references from the root, ending with the first reference of the
+	// cycle repeated.
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Path, " -> ")
+}
@@ -3,24 +3,54 @@ package resolution
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/andrewhowdencom/skr/pkg/parallel"
 	"github.com/andrewhowdencom/skr/pkg/store"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+// DependenciesAnnotation is the manifest annotation holding a JSON list of dependencies,
+// written alongside the skill config for compatibility with older versions of skr.
+const DependenciesAnnotation = "com.skr.dependencies"
+
 // PullFunc is a function that pulls a reference into the store.
 type PullFunc func(context.Context, string) error
 
+// TagListFunc is a function that lists the tags of a repository.
+type TagListFunc func(ctx context.Context, repository string) ([]string, error)
+
 // Resolver handles dependency resolution for skills.
 type Resolver struct {
-	store  *store.Store
-	puller PullFunc
+	store     *store.Store
+	puller    PullFunc
+	tagLister TagListFunc
+	policy    ConflictPolicy
+	reporter  func(Conflict)
+	jobs      int
+
+	// pulls deduplicates concurrent pulls of the same reference.
+	mu    sync.Mutex
+	pulls map[string]*pullCall
+}
+
+// pullCall is a pull in progress or done.
+type pullCall struct {
+	done chan struct{}
+	err  error
 }
 
 // New creates a new Resolver.
 func New(st *store.Store) *Resolver {
-	return &Resolver{store: st}
+	return &Resolver{store: st, jobs: parallel.DefaultJobs, pulls: make(map[string]*pullCall)}
+}
+
+// SetJobs sets how many artifacts are resolved and pulled at once.
+func (r *Resolver) SetJobs(jobs int) {
+	r.jobs = jobs
 }
 
 // SetPuller sets the function to call when an artifact is missing from the store.
@@ -28,71 +58,355 @@ func (r *Resolver) SetPuller(puller PullFunc) {
 	r.puller = puller
 }
 
+// SetConflictPolicy sets how conflicting artifacts are resolved. The default is PolicyFail.
+func (r *Resolver) SetConflictPolicy(policy ConflictPolicy) {
+	r.policy = policy
+}
+
+// SetConflictReporter sets a function called with each conflict the policy resolved.
+func (r *Resolver) SetConflictReporter(reporter func(Conflict)) {
+	r.reporter = reporter
+}
+
+// SetTagLister sets the function to list the tags of a repository when resolving a version
+// constraint. The tags in the store are always considered, and are the only ones without a
+// tag lister or when it fails.
+func (r *Resolver) SetTagLister(tagLister TagListFunc) {
+	r.tagLister = tagLister
+}
+
 // Resolve resolves the full list of artifacts required for the given root reference.
-// It returns a list of all unique artifacts (including dependencies) that need to be installed.
-// It uses BFS traversal and detects circular dependencies.
+// It returns a list of all unique artifacts (including dependencies) that need to be installed,
+// in breadth-first order starting with the root.
+//
+// A reference of the form repository@constraint, such as ghcr.io/org/git@^1.2, resolves to
+// the tag with the highest semantic version satisfying the constraint, and is returned
+// pinned to the digest of that tag, as in ghcr.io/org/git:1.2.5@sha256:....
+//
+// Resolve fails with a *CycleError if the dependencies form a cycle. Different artifacts of
+// the same repository, or with the same skill name, would be installed into the same
+// directory; they are resolved with the conflict policy, which fails with a *ConflictError
+// by default.
 func (r *Resolver) Resolve(ctx context.Context, rootRef string) ([]string, error) {
-	queue := []string{rootRef}
-	visited := make(map[string]bool)
-	var resolved []string
+	return r.ResolveAll(ctx, []string{rootRef})
+}
+
+// ResolveAll is like Resolve for several root references, which are resolved together so
+// that conflicts between their dependencies are found and each artifact is listed once.
+func (r *Resolver) ResolveAll(ctx context.Context, rootRefs []string) ([]string, error) {
+	g, err := r.Graph(ctx, rootRefs...)
+	if err != nil {
+		return nil, err
+	}
+	if cycle := g.Cycle(); cycle != nil {
+		return nil, &CycleError{Path: cycle}
+	}
+
+	replaced, err := r.resolveConflicts(g)
+	if err != nil {
+		return nil, err
+	}
+	return g.install(replaced), nil
+}
 
+// Referenced returns the references in the store that refs keep in use: each reference, every
+// local tag satisfying a version constraint, and, transitively, their dependencies. Unlike
+// Resolve it never pulls, and skips references missing from the store or that cannot be
+// read, as nothing is there to keep. A reference without a tag or digest keeps both the
+// untagged name and its "latest" tag.
+func (r *Resolver) Referenced(ctx context.Context, refs []string) ([]string, error) {
+	kept := []string{}
+	seen := make(map[string]bool)
+	queue := append([]string(nil), refs...)
 	for len(queue) > 0 {
-		currentRef := queue[0]
+		ref := queue[0]
 		queue = queue[1:]
-
-		if visited[currentRef] {
+		if seen[ref] {
 			continue
 		}
-		visited[currentRef] = true
-		resolved = append(resolved, currentRef)
+		seen[ref] = true
 
-		// Fetch Manifest to get dependencies from annotations
-		desc, err := r.store.Resolve(ctx, currentRef)
+		repository, constraint, err := SplitConstraint(ref)
 		if err != nil {
-			// Try pulling if configured
-			if r.puller != nil {
-				if pullErr := r.puller(ctx, currentRef); pullErr == nil {
-					// Retry resolve after pull
-					desc, err = r.store.Resolve(ctx, currentRef)
-				} else {
-					// Return original error wrapped with pull error context
-					return nil, fmt.Errorf("failed to resolve %s locally and pull failed: %v", currentRef, pullErr)
+			continue
+		}
+		if constraint != nil {
+			entries, err := r.store.Entries(ctx, store.WithRepository(repository))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list tags of %s: %w", repository, err)
+			}
+			for _, entry := range entries {
+				if v, err := ParseVersion(entry.Tag); err == nil && constraint.Check(v) {
+					queue = append(queue, repository+":"+entry.Tag)
 				}
 			}
+			continue
+		}
 
-			if err != nil {
-				return nil, fmt.Errorf("failed to resolve %s: %w", currentRef, err)
-			}
+		// A reference without a tag may be stored as written, or as ":latest"; keep both.
+		if parsed, err := store.ParseReference(ref); err == nil && parsed.Tag == "" && parsed.Digest == "" {
+			queue = append(queue, ref+":latest")
 		}
+		desc, err := r.resolveLocal(ctx, ref)
+		if err != nil {
+			continue
+		}
+		kept = append(kept, ref)
 
-		manifestReader, err := r.store.Fetch(ctx, desc)
+		manifest, err := r.store.Manifest(ctx, desc)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch manifest for %s: %w", currentRef, err)
+			continue
 		}
-		defer manifestReader.Close()
+		if _, deps, err := r.dependencies(ctx, manifest); err == nil {
+			queue = append(queue, deps...)
+		}
+	}
+	return kept, nil
+}
 
-		var manifest ocispec.Manifest
-		if err := json.NewDecoder(manifestReader).Decode(&manifest); err != nil {
-			return nil, fmt.Errorf("failed to decode manifest for %s: %w", currentRef, err)
+// Graph resolves the dependency graph of the given root references, pulling missing
+// artifacts if a puller is set.
+//
+// The graph is walked breadth-first, and the new dependencies of each level are resolved
+// concurrently, up to the number of jobs. The graph does not depend on the order in which
+// they finish.
+func (r *Resolver) Graph(ctx context.Context, rootRefs ...string) (*Graph, error) {
+	g := &Graph{Nodes: make(map[string]*Node)}
+
+	// resolvedRefs memoizes the reference each requested reference resolved to.
+	resolvedRefs := make(map[string]string)
+
+	roots, err := r.resolveNodes(ctx, rootRefs, nil, resolvedRefs)
+	if err != nil {
+		return nil, err
+	}
+	var level []*Node
+	for i, node := range roots {
+		if node == nil {
+			continue
+		}
+		resolvedRefs[rootRefs[i]] = node.Ref
+		if g.Nodes[node.Ref] == nil {
+			node.Path = []string{node.Ref}
+			g.add(node)
+			g.Roots = append(g.Roots, node)
+			level = append(level, node)
 		}
+	}
 
-		// Parse Dependencies from Annotation
-		if depsJSON, ok := manifest.Annotations["com.skr.dependencies"]; ok {
-			var deps []string
-			if err := json.Unmarshal([]byte(depsJSON), &deps); err != nil {
-				return nil, fmt.Errorf("failed to parse dependencies for %s: %w", currentRef, err)
+	for len(level) > 0 {
+		var pending []string
+		var parents []*Node
+		for _, current := range level {
+			for _, dep := range current.requires {
+				pending = append(pending, dep)
+				parents = append(parents, current)
 			}
+		}
+		nodes, err := r.resolveNodes(ctx, pending, parents, resolvedRefs)
+		if err != nil {
+			return nil, err
+		}
 
-			// Add unseen deps to queue
-			for _, dep := range deps {
-				// TODO: Better cycle detection / version conflict warning here?
-				// For now, naive unique string check.
-				if !visited[dep] {
-					queue = append(queue, dep)
+		var next []*Node
+		for i, dep := range pending {
+			current := parents[i]
+			if node := nodes[i]; node != nil {
+				resolvedRefs[dep] = node.Ref
+				if g.Nodes[node.Ref] == nil {
+					node.Path = append(append([]string(nil), current.Path...), node.Ref)
+					g.add(node)
+					next = append(next, node)
 				}
 			}
+			current.Dependencies = append(current.Dependencies, resolvedRefs[dep])
+		}
+		level = next
+	}
+
+	return g, nil
+}
+
+// resolveNodes resolves refs concurrently. References in resolved, or earlier in refs, are
+// skipped and their node is nil. parents, if given, are the dependents of refs.
+func (r *Resolver) resolveNodes(ctx context.Context, refs []string, parents []*Node, resolved map[string]string) ([]*Node, error) {
+	seen := make(map[string]bool)
+	var indexes []int
+	for i, ref := range refs {
+		if _, ok := resolved[ref]; ok || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		indexes = append(indexes, i)
+	}
+
+	nodes := make([]*Node, len(refs))
+	err := parallel.ForEach(ctx, r.jobs, len(indexes), func(ctx context.Context, j int) error {
+		i := indexes[j]
+		node, err := r.resolveNode(ctx, refs[i])
+		if err != nil {
+			if parents != nil {
+				return fmt.Errorf("%w (required by %s)", err, strings.Join(parents[i].Path, " -> "))
+			}
+			return err
+		}
+		nodes[i] = node
+		return nil
+	})
+	return nodes, err
+}
+
+// resolveNode resolves a single reference, without its dependencies.
+func (r *Resolver) resolveNode(ctx context.Context, ref string) (*Node, error) {
+	currentRef := ref
+	repository, constraint, err := SplitConstraint(ref)
+	if err != nil {
+		return nil, err
+	}
+	if constraint != nil {
+		tag, err := r.selectTag(ctx, repository, constraint)
+		if err != nil {
+			return nil, err
+		}
+		currentRef = repository + ":" + tag
+	}
+
+	// Fetch Manifest to get dependencies from annotations
+	desc, err := r.resolveLocal(ctx, currentRef)
+	if err != nil {
+		// Try pulling if configured
+		if r.puller != nil {
+			if pullErr := r.pull(ctx, currentRef); pullErr == nil {
+				// Retry resolve after pull
+				desc, err = r.resolveLocal(ctx, currentRef)
+			} else {
+				// Return original error wrapped with pull error context
+				return nil, fmt.Errorf("failed to resolve %s locally and pull failed: %v", currentRef, pullErr)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", currentRef, err)
+		}
+	}
+
+	if constraint != nil {
+		// Record the digest, so that installing the result is not affected by the tag moving.
+		currentRef += "@" + desc.Digest.String()
+	}
+
+	manifest, err := r.store.Manifest(ctx, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest for %s: %w", currentRef, err)
+	}
+
+	name, deps, err := r.dependencies(ctx, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dependencies for %s: %w", currentRef, err)
+	}
+
+	node := &Node{Ref: currentRef, Name: name, Digest: desc.Digest, requires: deps}
+	if parsed, err := store.ParseReference(currentRef); err == nil {
+		node.Repository, node.Tag = parsed.Repository, parsed.Tag
+	}
+	return node, nil
+}
+
+// pull pulls ref with the puller, once, however many dependents require it at the same time.
+func (r *Resolver) pull(ctx context.Context, ref string) error {
+	r.mu.Lock()
+	if call, ok := r.pulls[ref]; ok {
+		r.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+	call := &pullCall{done: make(chan struct{})}
+	r.pulls[ref] = call
+	r.mu.Unlock()
+
+	call.err = r.puller(ctx, ref)
+	close(call.done)
+	return call.err
+}
+
+// Pulled reports whether ref was pulled successfully while resolving, so that callers do not
+// need to pull it again.
+func (r *Resolver) Pulled(ref string) bool {
+	r.mu.Lock()
+	call, ok := r.pulls[ref]
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case <-call.done:
+		return call.err == nil
+	default:
+		return false
+	}
+}
+
+// resolveLocal resolves ref in the store. References pinned to a digest resolve by the
+// digest, as the store does not tag them.
+func (r *Resolver) resolveLocal(ctx context.Context, ref string) (ocispec.Descriptor, error) {
+	if parsed, err := store.ParseReference(ref); err == nil && parsed.Digest != "" {
+		return r.store.Resolve(ctx, parsed.Digest.String())
+	}
+	return r.store.Resolve(ctx, ref)
+}
+
+// selectTag returns the tag of repository with the highest semantic version satisfying
+// constraint. The tags in the store are considered along with those the tag lister finds,
+// so that constraints still resolve from the store when the registry cannot be reached.
+func (r *Resolver) selectTag(ctx context.Context, repository string, constraint *Constraint) (string, error) {
+	entries, err := r.store.Entries(ctx, store.WithRepository(repository))
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags of %s: %w", repository, err)
+	}
+	var tags []string
+	for _, entry := range entries {
+		tags = append(tags, entry.Tag)
+	}
+
+	var listErr error
+	if r.tagLister != nil {
+		remote, err := r.tagLister(ctx, repository)
+		if err != nil {
+			listErr = err
 		}
+		tags = append(tags, remote...)
 	}
 
-	return resolved, nil
+	tag, ok := constraint.Highest(tags)
+	if !ok {
+		if listErr != nil {
+			return "", fmt.Errorf("no version of %s in the store satisfies %s, and listing its tags failed: %w", repository, constraint, listErr)
+		}
+		return "", fmt.Errorf("no version of %s satisfies %s", repository, constraint)
+	}
+	return tag, nil
+}
+
+// dependencies returns the skill name and the dependencies recorded in the skill config,
+// falling back to the com.skr.dependencies annotation for artifacts built before the config
+// schema existed. The name is empty for those.
+func (r *Resolver) dependencies(ctx context.Context, manifest ocispec.Manifest) (string, []string, error) {
+	config, err := r.store.Config(ctx, manifest)
+	if err != nil && !errors.Is(err, store.ErrNotSkillConfig) {
+		return "", nil, err
+	}
+	if config != nil && config.SchemaVersion >= 1 {
+		name, _ := config.Frontmatter["name"].(string)
+		return name, config.Dependencies, nil
+	}
+
+	depsJSON, ok := manifest.Annotations[DependenciesAnnotation]
+	if !ok {
+		return "", nil, nil
+	}
+	var deps []string
+	if err := json.Unmarshal([]byte(depsJSON), &deps); err != nil {
+		return "", nil, err
+	}
+	return "", deps, nil
 }
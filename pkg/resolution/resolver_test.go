@@ -5,19 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/andrewhowdencom/skr/pkg/store"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2"
 )
 
 func TestResolve_PullMissing(t *testing.T) {
 	// Setup Store
-	tempDir := t.TempDir()
-	st, err := store.New(tempDir)
+	st, err := store.NewMemory()
 	require.NoError(t, err)
 
 	// Define artifacts and refs
@@ -96,4 +101,296 @@ func TestResolve_PullMissing(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, pullCalled, "Puller should have been called")
 	assert.Contains(t, resolved, rootRef)
+	assert.True(t, r.Pulled(rootRef), "the pull is recorded so that installs do not repeat it")
+	assert.False(t, r.Pulled("example.com/other:latest"))
+}
+
+func TestResolve_ConfigDependencies(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+
+	build := func(name, ref string, deps ...string) {
+		dir := t.TempDir()
+		content := "---\nname: " + name + "\ndescription: test\n"
+		if len(deps) > 0 {
+			content += "dependencies:\n"
+			for _, dep := range deps {
+				content += "  - " + dep + "\n"
+			}
+		}
+		content += "---\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644))
+		_, err := st.Build(ctx, dir, ref, nil)
+		require.NoError(t, err)
+	}
+	build("leaf", "example.com/leaf:v1")
+	build("middle", "example.com/middle:v1", "example.com/leaf:v1")
+	build("root", "example.com/root:v1", "example.com/middle:v1", "example.com/leaf:v1")
+
+	resolved, err := New(st).Resolve(ctx, "example.com/root:v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/root:v1", "example.com/middle:v1", "example.com/leaf:v1"}, resolved)
+}
+
+func TestResolve_VersionConstraints(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+
+	digests := make(map[string]string)
+	build := func(name, ref string, deps ...string) {
+		dir := t.TempDir()
+		content := "---\nname: " + name + "\ndescription: " + ref + "\n"
+		if len(deps) > 0 {
+			content += "dependencies:\n"
+			for _, dep := range deps {
+				content += "  - \"" + dep + "\"\n"
+			}
+		}
+		content += "---\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644))
+		desc, err := st.Build(ctx, dir, ref, nil)
+		require.NoError(t, err)
+		digests[ref] = desc.Digest.String()
+	}
+	build("git", "example.com/git:1.2.0")
+	build("git", "example.com/git:1.3.1")
+	build("git", "example.com/git:2.0.0")
+	build("root", "example.com/root:v1", "example.com/git@^1.2", "example.com/git@~1.3")
+
+	// Without a tag lister, the tags in the store are used.
+	resolved, err := New(st).Resolve(ctx, "example.com/root:v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"example.com/root:v1",
+		"example.com/git:1.3.1@" + digests["example.com/git:1.3.1"],
+	}, resolved)
+
+	// A tag lister lists the remote tags; missing versions are pulled.
+	r := New(st)
+	var listed []string
+	r.SetTagLister(func(ctx context.Context, repository string) ([]string, error) {
+		listed = append(listed, repository)
+		return []string{"1.2.0", "1.4.0", "2.0.0"}, nil
+	})
+	r.SetPuller(func(ctx context.Context, ref string) error {
+		assert.Equal(t, "example.com/git:1.4.0", ref)
+		desc, err := st.Resolve(ctx, "example.com/git:1.2.0")
+		require.NoError(t, err)
+		return st.Tag(ctx, desc, ref)
+	})
+	resolved, err = r.Resolve(ctx, "example.com/git@^1.2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/git:1.4.0@" + digests["example.com/git:1.2.0"]}, resolved)
+	assert.Equal(t, []string{"example.com/git"}, listed)
+
+	_, err = New(st).Resolve(ctx, "example.com/git@^3")
+	assert.ErrorContains(t, err, "no version of example.com/git satisfies ^3")
+
+	// When the registry cannot be reached, the tags in the store are used.
+	offline := New(st)
+	offline.SetTagLister(func(ctx context.Context, repository string) ([]string, error) {
+		return nil, fmt.Errorf("connection refused")
+	})
+	resolved, err = offline.Resolve(ctx, "example.com/git@~1.3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/git:1.3.1@" + digests["example.com/git:1.3.1"]}, resolved)
+
+	_, err = offline.Resolve(ctx, "example.com/git@^3")
+	assert.ErrorContains(t, err, "listing its tags failed: connection refused")
+}
+
+// buildSkills builds skills into st from refs mapped to "name dependency...".
+func buildSkills(t *testing.T, st *store.Store, skills map[string]string) {
+	t.Helper()
+	for ref, spec := range skills {
+		fields := strings.Fields(spec)
+		content := "---\nname: " + fields[0] + "\ndescription: " + ref + "\n"
+		if len(fields) > 1 {
+			content += "dependencies:\n"
+			for _, dep := range fields[1:] {
+				content += "  - \"" + dep + "\"\n"
+			}
+		}
+		content += "---\n"
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644))
+		_, err := st.Build(context.Background(), dir, ref, nil)
+		require.NoError(t, err)
+	}
+}
+
+func TestResolve_Cycle(t *testing.T) {
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+	buildSkills(t, st, map[string]string{
+		"example.com/root:v1": "root example.com/a:v1",
+		"example.com/a:v1":    "a example.com/b:v1",
+		"example.com/b:v1":    "b example.com/c:v1",
+		"example.com/c:v1":    "c example.com/a:v1",
+	})
+
+	_, err = New(st).Resolve(context.Background(), "example.com/root:v1")
+	var cycleErr *CycleError
+	require.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []string{"example.com/root:v1", "example.com/a:v1", "example.com/b:v1", "example.com/c:v1", "example.com/a:v1"}, cycleErr.Path)
+	assert.EqualError(t, err, "dependency cycle: example.com/root:v1 -> example.com/a:v1 -> example.com/b:v1 -> example.com/c:v1 -> example.com/a:v1")
+}
+
+func TestResolve_Conflicts(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+	buildSkills(t, st, map[string]string{
+		"example.com/root:v1":   "root example.com/git:1.0.0 example.com/mid:v1",
+		"example.com/mid:v1":    "mid example.com/git:2.0.0 example.com/other:v1",
+		"example.com/git:1.0.0": "git",
+		"example.com/git:2.0.0": "git example.com/extra:v1",
+		"example.com/extra:v1":  "extra",
+		"example.com/other:v1":  "other",
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		_, err := New(st).Resolve(ctx, "example.com/root:v1")
+		var conflictErr *ConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		require.Len(t, conflictErr.Conflicts, 1)
+		assert.Equal(t, ConflictRepository, conflictErr.Conflicts[0].Kind)
+		assert.Contains(t, err.Error(), "repository example.com/git is required as example.com/git:1.0.0 (by example.com/root:v1) and example.com/git:2.0.0 (by example.com/root:v1 -> example.com/mid:v1)")
+	})
+
+	tests := []struct {
+		policy   ConflictPolicy
+		expected []string
+	}{
+		{PolicyRoot, []string{"example.com/root:v1", "example.com/git:1.0.0", "example.com/mid:v1", "example.com/other:v1"}},
+		{PolicyHighest, []string{"example.com/root:v1", "example.com/git:2.0.0", "example.com/mid:v1", "example.com/extra:v1", "example.com/other:v1"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			r := New(st)
+			r.SetConflictPolicy(tt.policy)
+			var reported []Conflict
+			r.SetConflictReporter(func(c Conflict) { reported = append(reported, c) })
+
+			resolved, err := r.Resolve(ctx, "example.com/root:v1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resolved)
+			require.Len(t, reported, 1)
+			assert.Equal(t, tt.expected[1], reported[0].Chosen.Ref)
+		})
+	}
+}
+
+func TestResolve_NameConflict(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+	buildSkills(t, st, map[string]string{
+		"example.com/root:v1":      "root example.com/git:v1 example.com/fork/git:v1 example.com/alias:v1",
+		"example.com/git:v1":       "git",
+		"example.com/fork/git:v1":  "git",
+		"example.com/alias:latest": "alias",
+	})
+	// The same artifact under two tags is not a conflict.
+	desc, err := st.Resolve(ctx, "example.com/alias:latest")
+	require.NoError(t, err)
+	require.NoError(t, st.Tag(ctx, desc, "example.com/alias:v1"))
+
+	_, err = New(st).Resolve(ctx, "example.com/root:v1")
+	var conflictErr *ConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Len(t, conflictErr.Conflicts, 1)
+	assert.Equal(t, ConflictName, conflictErr.Conflicts[0].Kind)
+	assert.Equal(t, "git", conflictErr.Conflicts[0].Key)
+
+	// Tags that are not semantic versions cannot be compared.
+	r := New(st)
+	r.SetConflictPolicy(PolicyHighest)
+	_, err = r.Resolve(ctx, "example.com/root:v1")
+	assert.ErrorContains(t, err, "example.com/git:v1 is not tagged with a semantic version")
+
+	r.SetConflictPolicy(PolicyRoot)
+	resolved, err := r.Resolve(ctx, "example.com/root:v1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/root:v1", "example.com/git:v1", "example.com/alias:v1"}, resolved)
+}
+
+func TestResolveAll_Parallel(t *testing.T) {
+	ctx := context.Background()
+	remote, err := store.NewMemory()
+	require.NoError(t, err)
+	skills := map[string]string{
+		"example.com/shared:v1": "shared",
+		"example.com/git:1.2.0": "git example.com/shared:v1",
+		"example.com/git:1.3.0": "git example.com/shared:v1",
+	}
+	var roots []string
+	for i := 0; i < 12; i++ {
+		ref := fmt.Sprintf("example.com/skill-%02d:v1", i)
+		skills[ref] = fmt.Sprintf("skill-%02d example.com/shared:v1 example.com/git@^1.2 example.com/git@~1.3", i)
+		roots = append(roots, ref)
+	}
+	buildSkills(t, remote, skills)
+
+	resolve := func(jobs int) ([]string, map[string]int) {
+		st, err := store.NewMemory()
+		require.NoError(t, err)
+		var mu sync.Mutex
+		pulls := make(map[string]int)
+
+		r := New(st)
+		r.SetJobs(jobs)
+		r.SetTagLister(func(ctx context.Context, repository string) ([]string, error) {
+			return []string{"1.2.0", "1.3.0"}, nil
+		})
+		r.SetPuller(func(ctx context.Context, ref string) error {
+			mu.Lock()
+			pulls[ref]++
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			_, err := oras.Copy(ctx, remote, ref, st, ref, oras.DefaultCopyOptions)
+			return err
+		})
+		resolved, err := r.ResolveAll(ctx, roots)
+		require.NoError(t, err)
+		return resolved, pulls
+	}
+
+	serial, _ := resolve(1)
+	resolved, pulls := resolve(8)
+	assert.Equal(t, serial, resolved)
+	assert.Len(t, resolved, len(roots)+2)
+	assert.Equal(t, roots, resolved[:len(roots)])
+	for ref, n := range pulls {
+		assert.Equal(t, 1, n, "%s should be pulled once", ref)
+	}
+}
+
+func TestReferenced(t *testing.T) {
+	ctx := context.Background()
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+	buildSkills(t, st, map[string]string{
+		"example.com/root:v1":     "root example.com/git@^1.2 example.com/missing:v1",
+		"example.com/git:1.2.0":   "git example.com/lib:v1",
+		"example.com/git:1.3.0":   "git",
+		"example.com/git:2.0.0":   "git example.com/other:v1",
+		"example.com/lib:v1":      "lib",
+		"example.com/other:v1":    "other",
+		"example.com/tool:latest": "tool",
+		"example.com/untagged":    "untagged",
+	})
+
+	kept, err := New(st).Referenced(ctx, []string{"example.com/root:v1", "example.com/tool", "example.com/untagged", "example.com/absent:v1"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"example.com/root:v1",
+		"example.com/tool:latest",
+		"example.com/untagged",
+		"example.com/git:1.2.0",
+		"example.com/git:1.3.0",
+		"example.com/lib:v1",
+	}, kept)
 }
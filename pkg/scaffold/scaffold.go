@@ -0,0 +1,274 @@
+// Package scaffold creates new skill directories from templates.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/andrewhowdencom/skr/pkg/skill"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateSuffix marks template files that are rendered with text/template. The suffix is
+// removed from the name of the created file; other files are copied as they are.
+const TemplateSuffix = ".tmpl"
+
+// DefaultTemplate is the built-in template used when none is given.
+const DefaultTemplate = "default"
+
+//go:embed all:templates
+var builtin embed.FS
+
+// Template is a source of files for a new skill.
+type Template struct {
+	fsys fs.FS
+}
+
+// Data is the data templates are rendered with.
+type Data struct {
+	// Name is the name of the new skill.
+	Name string
+	// Description, Author and License are optional.
+	Description string
+	Author      string
+	License     string
+}
+
+// Builtin returns the built-in template with the given name.
+func Builtin(name string) (*Template, error) {
+	fsys, err := fs.Sub(builtin, path.Join("templates", name))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(fsys, "."); err != nil {
+		return nil, fmt.Errorf("unknown template %q (built-in templates: %s)", name, strings.Join(BuiltinNames(), ", "))
+	}
+	return &Template{fsys: fsys}, nil
+}
+
+// BuiltinNames lists the names of the built-in templates.
+func BuiltinNames() []string {
+	entries, _ := fs.ReadDir(builtin, "templates")
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltin reports whether name is the name of a built-in template.
+func IsBuiltin(name string) bool {
+	for _, n := range BuiltinNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FromDir returns a template reading its files from dir. Any directory works, including an
+// existing skill or one extracted from a skill artifact.
+func FromDir(dir string) *Template {
+	return &Template{fsys: os.DirFS(dir)}
+}
+
+// Create creates a new skill in dest, which must not exist yet.
+//
+// Files ending in TemplateSuffix are rendered with data, and other files are copied. The
+// name of the skill in SKILL.md is set to data.Name, and so is the description if one is
+// given, so that any skill can serve as a template. The references/, scripts/ and assets/
+// directories are always created, and files under scripts/ are made executable. The skill
+// is written to a temporary directory and only moved to dest once it is valid.
+func (t *Template) Create(dest string, data Data) error {
+	if err := skill.ValidateName(data.Name); err != nil {
+		return fmt.Errorf("invalid skill name %q: %w", data.Name, err)
+	}
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("%s already exists", dest)
+	}
+
+	parent := filepath.Dir(dest)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+	tmp, err := os.MkdirTemp(parent, ".skr-new-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := t.render(tmp, data); err != nil {
+		return err
+	}
+	for _, dir := range skill.ResourceDirs {
+		if err := os.MkdirAll(filepath.Join(tmp, dir), 0755); err != nil {
+			return err
+		}
+	}
+	if err := setFrontmatter(filepath.Join(tmp, skill.SkillFileName), data); err != nil {
+		return err
+	}
+
+	s, err := skill.LoadUnverified(tmp)
+	if err != nil {
+		return fmt.Errorf("template did not produce a valid skill: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("template did not produce a valid skill: %w", err)
+	}
+
+	// MkdirTemp creates the directory with mode 0700.
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// render writes the files of the template to dest.
+func (t *Template) render(dest string, data Data) error {
+	return fs.WalkDir(t.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != "." && d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dest, filepath.FromSlash(name)), 0755)
+		}
+
+		content, err := fs.ReadFile(t.fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read template file %s: %w", name, err)
+		}
+		target := name
+		if strings.HasSuffix(name, TemplateSuffix) {
+			target = strings.TrimSuffix(name, TemplateSuffix)
+			if content, err = execute(name, content, data); err != nil {
+				return err
+			}
+		}
+
+		mode := os.FileMode(0644)
+		if strings.HasPrefix(target, "scripts/") {
+			mode = 0755
+		} else if info, err := d.Info(); err == nil && info.Mode().Perm()&0111 != 0 {
+			mode = 0755
+		}
+		path := filepath.Join(dest, filepath.FromSlash(target))
+		if err := os.WriteFile(path, content, mode); err != nil {
+			return err
+		}
+		// WriteFile is subject to the umask; make executable bits stick.
+		return os.Chmod(path, mode)
+	})
+}
+
+// execute renders a template file.
+func execute(name string, content []byte, data Data) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// funcs are the functions available to templates.
+var funcs = template.FuncMap{
+	// yaml quotes a string as a YAML scalar, if needed.
+	"yaml": func(s string) (string, error) {
+		out, err := yaml.Marshal(s)
+		return strings.TrimSuffix(string(out), "\n"), err
+	},
+	// title turns a skill name such as "pdf-tools" into a heading such as "Pdf Tools".
+	"title": func(s string) string {
+		words := strings.Split(s, "-")
+		for i, w := range words {
+			if w != "" {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+		}
+		return strings.Join(words, " ")
+	},
+}
+
+// setFrontmatter sets the name, and the description if given, in the frontmatter of the
+// SKILL.md file at path, keeping the other keys, their order and comments.
+func setFrontmatter(path string, data Data) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("template does not contain a %s file", skill.SkillFileName)
+	}
+	if err != nil {
+		return err
+	}
+
+	frontmatter, body, err := skill.SplitFrontmatter(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s frontmatter: %w", skill.SkillFileName, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(frontmatter, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s frontmatter: %w", skill.SkillFileName, err)
+	}
+	if doc.Kind != yaml.DocumentNode {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s frontmatter must be a mapping", skill.SkillFileName)
+	}
+
+	setKey(mapping, "name", data.Name)
+	if data.Description != "" {
+		setKey(mapping, "description", data.Description)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	buf.WriteString("---\n")
+	buf.Write(body)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
+
+// setKey sets key in a mapping node to a string value, adding it if it does not exist.
+func setKey(mapping *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
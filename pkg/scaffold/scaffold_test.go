@@ -0,0 +1,94 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andrewhowdencom/skr/pkg/action"
+	"github.com/andrewhowdencom/skr/pkg/skill"
+	"github.com/andrewhowdencom/skr/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltin(t *testing.T) {
+	for _, name := range BuiltinNames() {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := Builtin(name)
+			require.NoError(t, err)
+
+			dest := filepath.Join(t.TempDir(), "pdf-tools")
+			require.NoError(t, tmpl.Create(dest, Data{Name: "pdf-tools", Description: "Extract text: tables and forms from PDF files.", License: "MIT"}))
+
+			s, diags := skill.Check(dest)
+			require.NotNil(t, s)
+			assert.Empty(t, diags, "a new skill should have no findings")
+			assert.Equal(t, "Extract text: tables and forms from PDF files.", s.Description)
+			for _, dir := range skill.ResourceDirs {
+				assert.DirExists(t, filepath.Join(dest, dir))
+			}
+		})
+	}
+
+	_, err := Builtin("nope")
+	assert.ErrorContains(t, err, `unknown template "nope"`)
+}
+
+func TestCreate_FromDir(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "scripts"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "SKILL.md"), []byte("---\n# house style\nname: house\ndescription: The house template\nmetadata:\n  team: docs\n---\n# House\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "NOTES.md.tmpl"), []byte("Notes for {{ .Name }} by {{ .Author }}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "scripts", "check.py"), []byte("print('ok')\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644))
+
+	dest := filepath.Join(t.TempDir(), "skills", "my-skill")
+	require.NoError(t, FromDir(src).Create(dest, Data{Name: "my-skill", Author: "jane"}))
+
+	content, err := os.ReadFile(filepath.Join(dest, "SKILL.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\n# house style\nname: my-skill\ndescription: The house template\nmetadata:\n  team: docs\n---\n# House\n", string(content))
+
+	notes, err := os.ReadFile(filepath.Join(dest, "NOTES.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "Notes for my-skill by jane\n", string(notes))
+
+	info, err := os.Stat(filepath.Join(dest, "scripts", "check.py"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	assert.NoDirExists(t, filepath.Join(dest, ".git"))
+
+	// The destination must not exist, and nothing is left behind on failure.
+	assert.ErrorContains(t, FromDir(src).Create(dest, Data{Name: "my-skill"}), "already exists")
+	assert.Error(t, FromDir(t.TempDir()).Create(filepath.Join(t.TempDir(), "empty"), Data{Name: "empty"}))
+	assert.ErrorContains(t, FromDir(src).Create(filepath.Join(t.TempDir(), "x"), Data{Name: "Bad Name"}), "invalid skill name")
+	entries, err := os.ReadDir(filepath.Dir(dest))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCreate_FromArtifact(t *testing.T) {
+	t.Setenv(store.SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	st, err := store.NewMemory()
+	require.NoError(t, err)
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "SKILL.md"), []byte("---\nname: house\ndescription: The house template\n---\n# House\n"), 0644))
+	_, err = st.Build(ctx, src, "example.com/templates/house:v1", nil)
+	require.NoError(t, err)
+
+	extracted := t.TempDir()
+	require.NoError(t, action.Extract(ctx, st, "example.com/templates/house:v1", extracted))
+
+	dest := filepath.Join(t.TempDir(), "from-oci")
+	require.NoError(t, FromDir(extracted).Create(dest, Data{Name: "from-oci", Description: "Made from the house template"}))
+
+	s, err := skill.Load(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "from-oci", s.Name)
+	assert.Equal(t, "Made from the house template", s.Description)
+}
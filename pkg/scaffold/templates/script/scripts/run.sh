@@ -0,0 +1,5 @@
+#!/bin/sh
+# TODO: implement the tool this skill provides.
+set -eu
+
+echo "Hello from the skill script"
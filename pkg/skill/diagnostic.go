@@ -0,0 +1,265 @@
+package skill
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/andrewhowdencom/skr/pkg/ignore"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is how serious a diagnostic is. Only errors make a skill invalid.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Rule is a check that Check runs against a skill.
+type Rule struct {
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+}
+
+var (
+	RuleSkillFile           = Rule{"skill-file", SeverityError, "The skill directory contains a readable SKILL.md file."}
+	RuleFrontmatter         = Rule{"frontmatter", SeverityError, "SKILL.md starts with well-formed YAML frontmatter."}
+	RuleNameRequired        = Rule{"name-required", SeverityError, "The frontmatter sets a name."}
+	RuleNameLength          = Rule{"name-length", SeverityError, "The name is 64 characters or less."}
+	RuleNameFormat          = Rule{"name-format", SeverityError, "The name contains only lowercase alphanumeric characters and hyphens."}
+	RuleNameDirectory       = Rule{"name-directory", SeverityWarning, "The name matches the name of the skill directory."}
+	RuleDescriptionRequired = Rule{"description-required", SeverityError, "The frontmatter sets a description."}
+	RuleDescriptionLength   = Rule{"description-length", SeverityError, "The description is 1024 characters or less."}
+	RuleDescriptionQuality  = Rule{"description-quality", SeverityWarning, "The description says what the skill does and when to use it."}
+	RuleCompatibilityLength = Rule{"compatibility-length", SeverityError, "The compatibility field is 500 characters or less."}
+	RuleUnknownKey          = Rule{"unknown-key", SeverityWarning, "The frontmatter only uses keys defined by the specification."}
+	RuleLicense             = Rule{"license", SeverityInfo, "The frontmatter declares a license."}
+	RuleBodyEmpty           = Rule{"body-empty", SeverityWarning, "SKILL.md has instructions after the frontmatter."}
+	RuleBrokenLink          = Rule{"broken-link", SeverityError, "Relative links refer to files that exist in the skill and are packaged by a build."}
+	RuleMissingPath         = Rule{"missing-path", SeverityWarning, "Paths into references/, scripts/ and assets/ mentioned in the text exist."}
+	RuleScriptExecutable    = Rule{"script-executable", SeverityWarning, "Files under scripts/ are executable."}
+	RuleUnreferencedFile    = Rule{"unreferenced-file", SeverityInfo, "Files under references/, scripts/ and assets/ are referenced from SKILL.md."}
+	RuleTokenBudget         = Rule{"token-budget", SeverityWarning, "The estimated token counts of SKILL.md and the reference files are within their budgets."}
+)
+
+// Rules lists every rule Check runs.
+var Rules = []Rule{
+	RuleSkillFile,
+	RuleFrontmatter,
+	RuleNameRequired,
+	RuleNameLength,
+	RuleNameFormat,
+	RuleNameDirectory,
+	RuleDescriptionRequired,
+	RuleDescriptionLength,
+	RuleDescriptionQuality,
+	RuleCompatibilityLength,
+	RuleUnknownKey,
+	RuleLicense,
+	RuleBodyEmpty,
+	RuleBrokenLink,
+	RuleMissingPath,
+	RuleScriptExecutable,
+	RuleUnreferencedFile,
+	RuleTokenBudget,
+}
+
+// minDescriptionWords is the fewest words a description needs to tell an agent both what
+// a skill does and when to use it.
+const minDescriptionWords = 5
+
+// Diagnostic is a single finding about a skill.
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	// Line is the 1-based line in File, or 0 if the finding is not about a specific line.
+	Line int `json:"line,omitempty"`
+	// Column is the 1-based column on Line, or 0 if unknown.
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the diagnostic as "file:line:column: severity: message [rule]".
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, d.Line)
+		if d.Column > 0 {
+			location = fmt.Sprintf("%s:%d", location, d.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, d.Severity, d.Message, d.Rule)
+}
+
+// Diagnostics is the list of findings about a skill.
+type Diagnostics []Diagnostic
+
+// Count returns the number of diagnostics with the given severity.
+func (ds Diagnostics) Count(severity Severity) int {
+	n := 0
+	for _, d := range ds {
+		if d.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func (ds Diagnostics) HasErrors() bool {
+	return ds.Count(SeverityError) > 0
+}
+
+// add appends a diagnostic for rule to ds.
+func (ds *Diagnostics) add(rule Rule, file string, line int, format string, args ...any) {
+	*ds = append(*ds, Diagnostic{
+		Rule:     rule.ID,
+		Severity: rule.Severity,
+		File:     file,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+type checkOptions struct {
+	budgets TokenBudgets
+}
+
+// CheckOption configures a call to Check.
+type CheckOption func(*checkOptions)
+
+// WithTokenBudgets sets the token budgets to check against. The default is
+// DefaultTokenBudgets.
+func WithTokenBudgets(b TokenBudgets) CheckOption {
+	return func(o *checkOptions) { o.budgets = b }
+}
+
+// Check loads the skill in dir and checks it against every rule, collecting all findings
+// rather than stopping at the first. The skill is nil if SKILL.md could not be read or its
+// frontmatter could not be parsed.
+func Check(dir string, opts ...CheckOption) (*Skill, Diagnostics) {
+	options := checkOptions{budgets: DefaultTokenBudgets}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var ds Diagnostics
+	file := filepath.Join(dir, SkillFileName)
+
+	content, err := readSkillFile(dir)
+	if err != nil {
+		ds.add(RuleSkillFile, file, 0, "%v", err)
+		return nil, ds
+	}
+
+	s, node, body, err := decodeFrontmatter(content)
+	if err != nil {
+		for _, fe := range frontmatterErrors(err) {
+			ds = append(ds, Diagnostic{
+				Rule:     RuleFrontmatter.ID,
+				Severity: RuleFrontmatter.Severity,
+				File:     file,
+				Line:     fe.Line,
+				Column:   fe.Column,
+				Message:  fe.Message,
+			})
+		}
+		return nil, ds
+	}
+	s.Path = dir
+
+	ds = append(ds, s.diagnose(file, keyLines(node))...)
+	if len(bytes.TrimSpace(body)) == 0 {
+		ds.add(RuleBodyEmpty, file, 0, "%s has no instructions after the frontmatter", SkillFileName)
+	}
+
+	// The body is the tail of content, so the lines before it are those of the frontmatter.
+	bodyLine := bytes.Count(content[:len(content)-len(body)], []byte("\n")) + 1
+	ds = append(ds, checkReferences(dir, file, body, bodyLine)...)
+
+	matcher, _ := ignore.Load(dir)
+	estimate := &TokenEstimate{Body: EstimateTokens(body), References: estimateReferences(dir, matcher)}
+	ds = append(ds, options.budgets.Check(dir, estimate)...)
+	return s, ds
+}
+
+// diagnose checks the metadata of the skill. lines maps frontmatter keys to the line they
+// are on in file, and may be nil.
+func (s *Skill) diagnose(file string, lines map[string]int) Diagnostics {
+	var ds Diagnostics
+
+	if s.Name == "" {
+		ds.add(RuleNameRequired, file, 0, "name is required")
+	} else {
+		if len(s.Name) > 64 {
+			ds.add(RuleNameLength, file, lines["name"], "name must be 64 characters or less")
+		}
+		if !validNameRegex.MatchString(s.Name) {
+			ds.add(RuleNameFormat, file, lines["name"], "name must contain only lowercase alphanumeric characters and hyphens")
+		}
+		if s.Path != "" {
+			if abs, err := filepath.Abs(s.Path); err == nil && filepath.Base(abs) != s.Name {
+				ds.add(RuleNameDirectory, file, lines["name"], "name %q does not match the directory name %q", s.Name, filepath.Base(abs))
+			}
+		}
+	}
+
+	if s.Description == "" {
+		ds.add(RuleDescriptionRequired, file, 0, "description is required")
+	} else {
+		if len(s.Description) > 1024 {
+			ds.add(RuleDescriptionLength, file, lines["description"], "description must be 1024 characters or less")
+		}
+		if len(strings.Fields(s.Description)) < minDescriptionWords {
+			ds.add(RuleDescriptionQuality, file, lines["description"],
+				"description has fewer than %d words; say what the skill does and when to use it", minDescriptionWords)
+		}
+	}
+
+	if len(s.Compatibility) > 500 {
+		ds.add(RuleCompatibilityLength, file, lines["compatibility"], "compatibility must be 500 characters or less")
+	}
+
+	if s.License == "" {
+		ds.add(RuleLicense, file, 0, "no license is declared")
+	}
+
+	keys := make([]string, 0, len(s.Extra))
+	for k := range s.Extra {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if lines[keys[i]] != lines[keys[j]] {
+			return lines[keys[i]] < lines[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		ds.add(RuleUnknownKey, file, lines[k], "unknown frontmatter key %q", k)
+	}
+
+	return ds
+}
+
+// keyLines maps each top-level frontmatter key to its line in SKILL.md. The frontmatter
+// starts on the line after the opening delimiter.
+func keyLines(node *yaml.Node) map[string]int {
+	lines := make(map[string]int)
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return lines
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		lines[node.Content[i].Value] = node.Content[i].Line + 1
+	}
+	return lines
+}
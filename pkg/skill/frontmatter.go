@@ -0,0 +1,177 @@
+package skill
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontmatterDelimiter = "---"
+
+// utf8BOM is the byte order mark some editors write at the start of UTF-8 files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// yamlErrorLine matches the line number yaml.v3 prefixes its error messages with.
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// FrontmatterError is an error in the frontmatter of a SKILL.md file. Line and Column are
+// 1-based positions in SKILL.md; either is 0 if unknown. The YAML parser reports only the
+// line of syntax errors.
+type FrontmatterError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *FrontmatterError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	default:
+		return e.Message
+	}
+}
+
+// SplitFrontmatter splits the content of a SKILL.md file into its YAML frontmatter and
+// markdown body.
+//
+// The frontmatter is delimited by lines consisting of "---", optionally followed by
+// whitespace. A UTF-8 byte order mark is ignored, and CRLF line endings in the frontmatter
+// are converted to LF. The frontmatter always starts on line 2 of the file.
+func SplitFrontmatter(content []byte) (frontmatter, body []byte, err error) {
+	content = bytes.TrimPrefix(content, utf8BOM)
+
+	line, rest := nextLine(content)
+	if !isDelimiter(line) {
+		return nil, nil, &FrontmatterError{Line: 1, Message: "missing frontmatter start delimiter '---'"}
+	}
+
+	start := len(content) - len(rest)
+	for offset := start; len(rest) > 0; offset = len(content) - len(rest) {
+		line, rest = nextLine(rest)
+		if isDelimiter(line) {
+			frontmatter = bytes.ReplaceAll(content[start:offset], []byte("\r\n"), []byte("\n"))
+			if len(rest) > 0 {
+				body = rest
+			}
+			return frontmatter, body, nil
+		}
+	}
+	return nil, nil, &FrontmatterError{Line: 1, Message: "missing frontmatter end delimiter '---'"}
+}
+
+// nextLine returns the first line of b, without its line ending, and the rest of b.
+func nextLine(b []byte) (line, rest []byte) {
+	if i := bytes.IndexByte(b, '\n'); i != -1 {
+		return bytes.TrimSuffix(b[:i], []byte("\r")), b[i+1:]
+	}
+	return bytes.TrimSuffix(b, []byte("\r")), nil
+}
+
+// isDelimiter reports whether line is a frontmatter delimiter. Lines such as "---text"
+// are not.
+func isDelimiter(line []byte) bool {
+	return string(bytes.TrimRight(line, " \t")) == frontmatterDelimiter
+}
+
+func parseFrontmatter(content []byte) (*Skill, error) {
+	s, _, _, err := decodeFrontmatter(content)
+	return s, err
+}
+
+// decodeFrontmatter parses the frontmatter of a SKILL.md file into a skill, also returning
+// the YAML node it was decoded from and the body. Errors are *FrontmatterError, or several
+// of them joined.
+func decodeFrontmatter(content []byte) (*Skill, *yaml.Node, []byte, error) {
+	frontmatter, body, err := SplitFrontmatter(content)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(frontmatter, &node); err != nil {
+		return nil, nil, nil, frontmatterError(err, nil)
+	}
+
+	var s Skill
+	if node.Kind == 0 {
+		// Empty frontmatter.
+		return &s, &node, body, nil
+	}
+	if err := node.Decode(&s); err != nil {
+		return nil, nil, nil, frontmatterError(err, &node)
+	}
+	return &s, &node, body, nil
+}
+
+// frontmatterError converts a yaml.v3 error into one or more *FrontmatterError, moving
+// line numbers from the frontmatter to SKILL.md. If node is set, the column is looked up
+// from the nodes on the reported line.
+func frontmatterError(err error, node *yaml.Node) error {
+	var messages []string
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+
+	errs := make([]error, 0, len(messages))
+	for _, message := range messages {
+		fe := &FrontmatterError{Message: message}
+		if m := yamlErrorLine.FindStringSubmatch(message); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			fe.Line = line + 1
+			fe.Column = columnAt(node, line)
+			fe.Message = m[2]
+		}
+		errs = append(errs, fe)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// columnAt returns the column of the leftmost value on the given line of the frontmatter,
+// which is where yaml.v3 reports decoding errors, or 0 if there is none.
+func columnAt(node *yaml.Node, line int) int {
+	column := 0
+	// candidate is false for keys and for the document and its root mapping.
+	var walk func(n *yaml.Node, candidate bool)
+	walk = func(n *yaml.Node, candidate bool) {
+		if candidate && n.Line == line && (column == 0 || n.Column < column) {
+			column = n.Column
+		}
+		for i, child := range n.Content {
+			isKey := n.Kind == yaml.MappingNode && i%2 == 0
+			walk(child, n.Kind != yaml.DocumentNode && !isKey)
+		}
+	}
+	if node != nil {
+		walk(node, false)
+	}
+	return column
+}
+
+// frontmatterErrors returns the *FrontmatterError values in err.
+func frontmatterErrors(err error) []*FrontmatterError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []*FrontmatterError
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, frontmatterErrors(e)...)
+		}
+		return errs
+	}
+	var fe *FrontmatterError
+	if errors.As(err, &fe) {
+		return []*FrontmatterError{fe}
+	}
+	return []*FrontmatterError{{Message: err.Error()}}
+}
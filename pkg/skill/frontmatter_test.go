@@ -0,0 +1,117 @@
+package skill
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		wantFrontmatter string
+		wantBody        string
+		wantErr         string
+	}{
+		{
+			name:            "LF",
+			content:         "---\nname: x\n---\n# Body\n",
+			wantFrontmatter: "name: x\n",
+			wantBody:        "# Body\n",
+		},
+		{
+			name:            "CRLF",
+			content:         "---\r\nname: x\r\ndescription: y\r\n---\r\n# Body\r\n",
+			wantFrontmatter: "name: x\ndescription: y\n",
+			wantBody:        "# Body\r\n",
+		},
+		{
+			name:            "Byte Order Mark",
+			content:         "\xEF\xBB\xBF---\nname: x\n---\n",
+			wantFrontmatter: "name: x\n",
+		},
+		{
+			name:            "Trailing Whitespace On Delimiters",
+			content:         "--- \nname: x\n---\t\n# Body\n",
+			wantFrontmatter: "name: x\n",
+			wantBody:        "# Body\n",
+		},
+		{
+			name:            "Dashes Followed By Text Are Not A Delimiter",
+			content:         "---\nname: x\n---text\n---\n# Body\n---more\n",
+			wantFrontmatter: "name: x\n---text\n",
+			wantBody:        "# Body\n---more\n",
+		},
+		{
+			name:            "Empty Frontmatter",
+			content:         "---\n---\n# Body\n",
+			wantFrontmatter: "",
+			wantBody:        "# Body\n",
+		},
+		{
+			name:            "No Newline After Closing Delimiter",
+			content:         "---\nname: x\n---",
+			wantFrontmatter: "name: x\n",
+		},
+		{
+			name:    "Missing Start",
+			content: "---text\nname: x\n---\n",
+			wantErr: "line 1: missing frontmatter start delimiter '---'",
+		},
+		{
+			name:    "Missing End",
+			content: "---\nname: x\n---text\n",
+			wantErr: "line 1: missing frontmatter end delimiter '---'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frontmatter, body, err := SplitFrontmatter([]byte(tt.content))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFrontmatter, string(frontmatter))
+			assert.Equal(t, tt.wantBody, string(body))
+		})
+	}
+}
+
+func TestParseFrontmatter_ErrorPosition(t *testing.T) {
+	// Syntax errors: yaml.v3 only reports the line.
+	_, err := parseFrontmatter([]byte("---\nname: x\ndescription: a: b\n---\n"))
+	var fe *FrontmatterError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, 3, fe.Line)
+
+	// Decoding errors also carry the column of the offending value.
+	_, err = parseFrontmatter([]byte("---\nname: x\ndescription:   [a, b]\n---\n"))
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, 3, fe.Line)
+	assert.Equal(t, 16, fe.Column)
+	assert.Contains(t, err.Error(), "line 3, column 16: ")
+
+	_, err = parseFrontmatter([]byte("---\r\nname: x\r\nallowed-tools:\r\n  tool: Read\r\n---\r\n"))
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, 4, fe.Line)
+	assert.Equal(t, "allowed-tools must be a string or a list of strings", fe.Message)
+}
+
+func TestCheck_FrontmatterErrors(t *testing.T) {
+	dir := t.TempDir()
+	content := "---\nname: [a]\ndescription: {b: c}\n---\n"
+	writeSkill(t, dir, content)
+
+	s, diags := Check(dir)
+	assert.Nil(t, s)
+	require.Len(t, diags, 2)
+	assert.Equal(t, "frontmatter", diags[0].Rule)
+	assert.Equal(t, 2, diags[0].Line)
+	assert.Equal(t, 7, diags[0].Column)
+	assert.Equal(t, 3, diags[1].Line)
+	assert.Equal(t, 14, diags[1].Column)
+}
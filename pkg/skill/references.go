@@ -0,0 +1,234 @@
+package skill
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/andrewhowdencom/skr/pkg/ignore"
+)
+
+// ResourceDirs are the optional directories of a skill whose files are meant to be
+// referenced from SKILL.md.
+var ResourceDirs = []string{"references", "scripts", "assets"}
+
+var (
+	// markdownLink matches inline links and images, capturing the destination.
+	markdownLink = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	// markdownDefinition matches link reference definitions, capturing the destination.
+	markdownDefinition = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	// pathMention matches paths into the resource directories written in prose or code,
+	// such as "run scripts/extract.py".
+	pathMention = regexp.MustCompile(`(?:^|[\s(\x60'"])((?:references|scripts|assets)/[\w.\-/]*[\w\-])`)
+	// uriScheme matches destinations with a scheme, which are not files in the skill.
+	uriScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.\-]*:`)
+)
+
+// reference is a file path found in a markdown document.
+type reference struct {
+	line   int
+	target string
+	// link is set for markdown links, which resolve relative to the document. Path
+	// mentions resolve relative to the skill directory.
+	link bool
+}
+
+// checkReferences checks the links and path mentions in the SKILL.md body, and in any
+// markdown file it references, against the files in dir. bodyLine is the line of SKILL.md
+// the body starts on.
+func checkReferences(dir, file string, body []byte, bodyLine int) Diagnostics {
+	var ds Diagnostics
+
+	// An invalid .skrignore fails the build anyway; without it, nothing is excluded.
+	matcher, _ := ignore.Load(dir)
+
+	referenced := make(map[string]bool)
+	scanned := map[string]bool{SkillFileName: true}
+	type document struct {
+		name  string
+		data  []byte
+		first int
+	}
+	queue := []document{{name: SkillFileName, data: body, first: bodyLine}}
+
+	for len(queue) > 0 {
+		doc := queue[0]
+		queue = queue[1:]
+		docFile := filepath.Join(dir, filepath.FromSlash(doc.name))
+		if doc.name == SkillFileName {
+			docFile = file
+		}
+
+		for _, ref := range findReferences(doc.data, doc.first) {
+			target, ok := resolveReference(doc.name, ref)
+			if !ok {
+				ds.add(RuleBrokenLink, docFile, ref.line, "link %q points outside the skill directory", ref.target)
+				continue
+			}
+
+			info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(target)))
+			switch {
+			case err != nil && ref.link:
+				ds.add(RuleBrokenLink, docFile, ref.line, "link %q refers to a file that does not exist", ref.target)
+				continue
+			case err != nil:
+				ds.add(RuleMissingPath, docFile, ref.line, "%q does not exist", ref.target)
+				continue
+			case matcher != nil && isExcluded(matcher, target, info.IsDir()):
+				ds.add(RuleBrokenLink, docFile, ref.line, "%q is excluded from the build", ref.target)
+				continue
+			}
+
+			referenced[target] = true
+			if !info.IsDir() && path.Ext(target) == ".md" && !scanned[target] {
+				scanned[target] = true
+				if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(target))); err == nil {
+					queue = append(queue, document{name: target, data: data, first: 1})
+				}
+			}
+		}
+	}
+
+	for _, name := range resourceFiles(dir, matcher) {
+		if !isReferenced(referenced, name) {
+			ds.add(RuleUnreferencedFile, filepath.Join(dir, filepath.FromSlash(name)), 0, "%s is not referenced from %s", name, SkillFileName)
+		}
+		if strings.HasPrefix(name, "scripts/") && runtime.GOOS != "windows" {
+			if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil && info.Mode().Perm()&0111 == 0 {
+				ds.add(RuleScriptExecutable, filepath.Join(dir, filepath.FromSlash(name)), 0, "%s is not executable", name)
+			}
+		}
+	}
+
+	return ds
+}
+
+// findReferences returns the links and path mentions in a markdown document, whose first
+// line is line first of its file. Links in fenced code blocks are ignored, but path
+// mentions are not, as code blocks often show how to run a script.
+func findReferences(data []byte, first int) []reference {
+	var refs []reference
+	inFence := false
+	line := first - 1
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+
+		links := make(map[string]bool)
+		if !inFence {
+			var matches [][]string
+			matches = append(matches, markdownLink.FindAllStringSubmatch(text, -1)...)
+			matches = append(matches, markdownDefinition.FindAllStringSubmatch(text, -1)...)
+			for _, m := range matches {
+				target := m[1]
+				if target == "" || strings.HasPrefix(target, "#") || uriScheme.MatchString(target) {
+					continue
+				}
+				links[strings.TrimPrefix(stripFragment(target), "./")] = true
+				refs = append(refs, reference{line: line, target: target, link: true})
+			}
+		}
+
+		for _, m := range pathMention.FindAllStringSubmatch(text, -1) {
+			if !links[m[1]] {
+				refs = append(refs, reference{line: line, target: m[1]})
+			}
+		}
+	}
+	return refs
+}
+
+// resolveReference returns the slash-separated path, relative to the skill directory, that
+// ref in the document name refers to. It reports false if the path leaves the directory.
+func resolveReference(name string, ref reference) (string, bool) {
+	target := stripFragment(ref.target)
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	if strings.HasPrefix(target, "/") {
+		return "", false
+	}
+	if ref.link {
+		target = path.Join(path.Dir(name), target)
+	}
+	target = path.Clean(target)
+	return target, filepath.IsLocal(filepath.FromSlash(target))
+}
+
+// stripFragment removes the query and fragment from a link destination.
+func stripFragment(target string) string {
+	if i := strings.IndexAny(target, "?#"); i != -1 {
+		return target[:i]
+	}
+	return target
+}
+
+// resourceFiles lists the files in the resource directories of dir that are packaged by
+// a build, as slash-separated paths relative to dir.
+func resourceFiles(dir string, matcher *ignore.Matcher) []string {
+	var names []string
+	for _, resourceDir := range ResourceDirs {
+		root := filepath.Join(dir, resourceDir)
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if matcher != nil && matcher.Match(rel, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.IsDir() {
+				names = append(names, rel)
+			}
+			return nil
+		})
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isReferenced reports whether name, or a directory containing it, was referenced.
+func isReferenced(referenced map[string]bool, name string) bool {
+	for p := name; p != "." && p != "/"; p = path.Dir(p) {
+		if referenced[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcluded reports whether name, or a directory containing it, is excluded by matcher.
+func isExcluded(matcher *ignore.Matcher, name string, isDir bool) bool {
+	if matcher.Match(name, isDir) {
+		return true
+	}
+	for p := path.Dir(name); p != "."; p = path.Dir(p) {
+		if matcher.Match(p, true) {
+			return true
+		}
+	}
+	return false
+}
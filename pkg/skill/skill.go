@@ -1,27 +1,72 @@
 package skill
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Skill represents the metadata and structure of an Agent Skill
 type Skill struct {
-	Name         string   `yaml:"name"`
-	Description  string   `yaml:"description"`
-	Dependencies []string `yaml:"dependencies,omitempty"`
-	Metadata     struct {
-		Author  string `yaml:"author,omitempty"`
-		Version string `yaml:"version,omitempty"`
-	} `yaml:"metadata,omitempty"`
+	Name          string            `yaml:"name"`
+	Description   string            `yaml:"description"`
+	License       string            `yaml:"license,omitempty"`
+	Compatibility string            `yaml:"compatibility,omitempty"`
+	AllowedTools  AllowedTools      `yaml:"allowed-tools,omitempty"`
+	Metadata      map[string]string `yaml:"metadata,omitempty"`
+	Dependencies  []string          `yaml:"dependencies,omitempty"`
+
+	// Extra holds any other frontmatter keys, so that they survive a round-trip.
+	Extra map[string]any `yaml:",inline"`
+
 	Path string `yaml:"-"` // Local path to the skill directory
 }
 
+// AllowedTools lists the tools a skill is pre-approved to use. The specification writes it
+// as a space-delimited string; a YAML list is accepted too.
+type AllowedTools []string
+
+// UnmarshalYAML accepts a space-delimited string or a list of strings.
+func (t *AllowedTools) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = strings.Fields(node.Value)
+		return nil
+	}
+	var tools []string
+	if err := node.Decode(&tools); err != nil {
+		return &yaml.TypeError{Errors: []string{
+			fmt.Sprintf("line %d: allowed-tools must be a string or a list of strings", node.Line),
+		}}
+	}
+	*t = tools
+	return nil
+}
+
+// MarshalYAML writes the tools as a space-delimited string.
+func (t AllowedTools) MarshalYAML() (any, error) {
+	return strings.Join(t, " "), nil
+}
+
+// Author returns the author recorded in the metadata, if any.
+func (s *Skill) Author() string {
+	return s.Metadata["author"]
+}
+
+// Version returns the version recorded in the metadata, if any.
+func (s *Skill) Version() string {
+	return s.Metadata["version"]
+}
+
+// MarshalFrontmatter encodes the skill as YAML frontmatter, including any unknown keys.
+func (s *Skill) MarshalFrontmatter() ([]byte, error) {
+	return yaml.Marshal(s)
+}
+
 const (
 	SkillFileName = "SKILL.md"
 )
@@ -47,6 +92,22 @@ func Load(dir string) (*Skill, error) {
 // LoadUnverified reads a skill from the given directory path without validating it.
 // This is useful for installing skills that might have legacy or non-compliant metadata but are otherwise functional.
 func LoadUnverified(dir string) (*Skill, error) {
+	content, err := readSkillFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	skill, err := parseFrontmatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s frontmatter: %w", SkillFileName, err)
+	}
+	skill.Path = dir
+
+	return skill, nil
+}
+
+// readSkillFile reads the SKILL.md file in dir.
+func readSkillFile(dir string) ([]byte, error) {
 	skillPath := filepath.Join(dir, SkillFileName)
 
 	info, err := os.Stat(skillPath)
@@ -64,60 +125,30 @@ func LoadUnverified(dir string) (*Skill, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read %s: %w", SkillFileName, err)
 	}
-
-	skill, err := parseFrontmatter(content)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse %s frontmatter: %w", SkillFileName, err)
-	}
-	skill.Path = dir
-
-	return skill, nil
+	return content, nil
 }
 
-// Validate checks if the skill metadata is valid according to the specification.
-func (s *Skill) Validate() error {
-	if s.Name == "" {
+// ValidateName checks that name is a valid skill name.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
 		return fmt.Errorf("name is required")
-	}
-	if len(s.Name) > 64 {
+	case len(name) > 64:
 		return fmt.Errorf("name must be 64 characters or less")
-	}
-	if !validNameRegex.MatchString(s.Name) {
+	case !validNameRegex.MatchString(name):
 		return fmt.Errorf("name must contain only lowercase alphanumeric characters and hyphens")
 	}
-
-	if s.Description == "" {
-		return fmt.Errorf("description is required")
-	}
-	if len(s.Description) > 1024 {
-		return fmt.Errorf("description must be 1024 characters or less")
-	}
-
-	// Validate directory structure matches name (warning or error?)
-	// Strictly speaking, the spec says name "Should match the directory name".
-	// We won't enforce it as a hard error here but it's good practice.
-
 	return nil
 }
 
-func parseFrontmatter(content []byte) (*Skill, error) {
-	// Frontmatter is anticipated to be between the first two "---" lines
-	if !bytes.HasPrefix(content, []byte("---\n")) {
-		return nil, fmt.Errorf("missing frontmatter start delimiter '---'")
-	}
-
-	end := bytes.Index(content[4:], []byte("\n---"))
-	if end == -1 {
-		return nil, fmt.Errorf("missing frontmatter end delimiter '---'")
-	}
-
-	// Adjust index to account for skipping the first 4 bytes
-	frontmatter := content[4 : 4+end]
-
-	var s Skill
-	if err := yaml.Unmarshal(frontmatter, &s); err != nil {
-		return nil, err
+// Validate checks if the skill metadata is valid according to the specification, and
+// returns the first error found. Warnings, such as a name that does not match the
+// directory, do not make a skill invalid; use Check to collect every finding.
+func (s *Skill) Validate() error {
+	for _, d := range s.diagnose(SkillFileName, nil) {
+		if d.Severity == SeverityError {
+			return errors.New(d.Message)
+		}
 	}
-
-	return &s, nil
+	return nil
 }
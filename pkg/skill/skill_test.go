@@ -0,0 +1,151 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fullFrontmatter = `---
+name: pdf-processing
+description: Extract text and tables from PDF files.
+license: Apache-2.0
+compatibility: Requires python3 and network access
+allowed-tools: Bash(git:*) Read
+metadata:
+  author: example-org
+  version: 1.0
+dependencies:
+  - ghcr.io/example/base:v1
+x-team: documents
+x-review:
+  owner: alice
+---
+# PDF Processing
+`
+
+func TestLoad_FullFrontmatter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SkillFileName), []byte(fullFrontmatter), 0644))
+
+	s, err := Load(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Apache-2.0", s.License)
+	assert.Equal(t, "Requires python3 and network access", s.Compatibility)
+	assert.Equal(t, AllowedTools{"Bash(git:*)", "Read"}, s.AllowedTools)
+	assert.Equal(t, map[string]string{"author": "example-org", "version": "1.0"}, s.Metadata)
+	assert.Equal(t, "example-org", s.Author())
+	assert.Equal(t, "1.0", s.Version())
+	assert.Equal(t, []string{"ghcr.io/example/base:v1"}, s.Dependencies)
+	assert.Equal(t, map[string]any{"x-team": "documents", "x-review": map[string]any{"owner": "alice"}}, s.Extra)
+
+	// Unknown keys survive a round-trip.
+	data, err := s.MarshalFrontmatter()
+	require.NoError(t, err)
+	roundTripped, err := parseFrontmatter(append(append([]byte("---\n"), data...), "---\n"...))
+	require.NoError(t, err)
+	roundTripped.Path = s.Path
+	assert.Equal(t, s, roundTripped)
+}
+
+func TestAllowedTools_List(t *testing.T) {
+	s, err := parseFrontmatter([]byte("---\nname: x\ndescription: y\nallowed-tools:\n  - Read\n  - Write\n---\n"))
+	require.NoError(t, err)
+	assert.Equal(t, AllowedTools{"Read", "Write"}, s.AllowedTools)
+
+	_, err = parseFrontmatter([]byte("---\nname: x\ndescription: y\nallowed-tools:\n  tool: Read\n---\n"))
+	assert.Error(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pdf-tools")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	content := "---\nname: Pdf_Processing\ndescription: PDFs\nx-team: documents\n---\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SkillFileName), []byte(content), 0644))
+
+	s, diags := Check(dir)
+	require.NotNil(t, s)
+
+	file := filepath.Join(dir, SkillFileName)
+	assert.Equal(t, Diagnostics{
+		{Rule: "name-format", Severity: SeverityError, File: file, Line: 2, Message: "name must contain only lowercase alphanumeric characters and hyphens"},
+		{Rule: "name-directory", Severity: SeverityWarning, File: file, Line: 2, Message: `name "Pdf_Processing" does not match the directory name "pdf-tools"`},
+		{Rule: "description-quality", Severity: SeverityWarning, File: file, Line: 3, Message: "description has fewer than 5 words; say what the skill does and when to use it"},
+		{Rule: "license", Severity: SeverityInfo, File: file, Message: "no license is declared"},
+		{Rule: "unknown-key", Severity: SeverityWarning, File: file, Line: 4, Message: `unknown frontmatter key "x-team"`},
+		{Rule: "body-empty", Severity: SeverityWarning, File: file, Message: "SKILL.md has no instructions after the frontmatter"},
+	}, diags)
+	assert.True(t, diags.HasErrors())
+	assert.Equal(t, 4, diags.Count(SeverityWarning))
+
+	// Validate only fails on errors, and reports the first.
+	assert.EqualError(t, s.Validate(), "name must contain only lowercase alphanumeric characters and hyphens")
+	s.Name = "pdf-processing"
+	assert.NoError(t, s.Validate())
+}
+
+func TestCheck_MissingFile(t *testing.T) {
+	s, diags := Check(t.TempDir())
+	assert.Nil(t, s)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "skill-file", diags[0].Rule)
+	assert.True(t, diags.HasErrors())
+}
+
+func writeSkill(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SkillFileName), []byte(content), 0644))
+}
+
+func TestCheck_References(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pdf")
+	for name, mode := range map[string]os.FileMode{
+		"references/forms.md":   0644,
+		"references/fields.md":  0644,
+		"references/unused.md":  0644,
+		"scripts/extract.py":    0755,
+		"scripts/fill.py":       0644,
+		"assets/logo.png":       0644,
+		"assets/drafts/todo.md": 0644,
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), mode))
+	}
+	require.NoError(t, os.Chmod(filepath.Join(dir, "scripts/fill.py"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".skrignore"), []byte("assets/drafts/\n"), 0644))
+	// forms.md links on to fields.md, relative to its own directory.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "references/forms.md"), []byte("See [fields](fields.md#top).\n"), 0644))
+
+	writeSkill(t, dir, `---
+name: pdf
+description: Extract text and tables from PDF files.
+license: MIT
+---
+Read [forms](references/forms.md) and ![logo](./assets/logo.png).
+Run scripts/fill.py, or scripts/missing.sh.
+
+`+"```"+`
+[not a link](references/none.md)
+python scripts/extract.py
+`+"```"+`
+Links: [gone](references/gone.md), [up](../other/SKILL.md), [web](https://example.com), [drafts](assets/drafts/todo.md).
+`)
+
+	_, diags := Check(dir)
+
+	file := filepath.Join(dir, SkillFileName)
+	assert.Equal(t, Diagnostics{
+		{Rule: "missing-path", Severity: SeverityWarning, File: file, Line: 7, Message: `"scripts/missing.sh" does not exist`},
+		// Inside a code block, the link is only a path mention.
+		{Rule: "missing-path", Severity: SeverityWarning, File: file, Line: 10, Message: `"references/none.md" does not exist`},
+		{Rule: "broken-link", Severity: SeverityError, File: file, Line: 13, Message: `link "references/gone.md" refers to a file that does not exist`},
+		{Rule: "broken-link", Severity: SeverityError, File: file, Line: 13, Message: `link "../other/SKILL.md" points outside the skill directory`},
+		{Rule: "broken-link", Severity: SeverityError, File: file, Line: 13, Message: `"assets/drafts/todo.md" is excluded from the build`},
+		{Rule: "unreferenced-file", Severity: SeverityInfo, File: filepath.Join(dir, "references/unused.md"), Message: "references/unused.md is not referenced from SKILL.md"},
+		{Rule: "script-executable", Severity: SeverityWarning, File: filepath.Join(dir, "scripts/fill.py"), Message: "scripts/fill.py is not executable"},
+	}, diags)
+}
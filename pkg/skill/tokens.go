@@ -0,0 +1,170 @@
+package skill
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andrewhowdencom/skr/pkg/ignore"
+)
+
+// Annotations recording the estimated token counts of a skill artifact.
+const (
+	AnnotationTokensBody       = "com.skr.tokens.body"
+	AnnotationTokensReferences = "com.skr.tokens.references"
+)
+
+// charsPerToken is the average number of characters per token of English prose and code
+// for common model tokenizers.
+const charsPerToken = 4
+
+// EstimateTokens estimates how many tokens text takes up in a model's context. It is a
+// heuristic of one token per four characters; actual counts depend on the tokenizer.
+func EstimateTokens(text []byte) int {
+	return (utf8.RuneCount(text) + charsPerToken - 1) / charsPerToken
+}
+
+// TokenEstimate is the estimated token count of the parts of a skill an agent loads: the
+// SKILL.md body when the skill is activated, and each reference file on demand.
+type TokenEstimate struct {
+	Body int `json:"body"`
+	// References maps reference files, relative to the skill directory, to their estimate.
+	References map[string]int `json:"references,omitempty"`
+}
+
+// ReferencesTotal returns the estimate of all reference files together.
+func (e *TokenEstimate) ReferencesTotal() int {
+	total := 0
+	for _, n := range e.References {
+		total += n
+	}
+	return total
+}
+
+// Total returns the estimate of the body and all reference files together.
+func (e *TokenEstimate) Total() int {
+	return e.Body + e.ReferencesTotal()
+}
+
+// Annotations returns the estimate as manifest annotations.
+func (e *TokenEstimate) Annotations() map[string]string {
+	return map[string]string{
+		AnnotationTokensBody:       strconv.Itoa(e.Body),
+		AnnotationTokensReferences: strconv.Itoa(e.ReferencesTotal()),
+	}
+}
+
+// EstimateSkillTokens estimates the tokens of the SKILL.md body in dir and of each text
+// file under references/ that a build packages.
+func EstimateSkillTokens(dir string) (*TokenEstimate, error) {
+	content, err := readSkillFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	_, body, err := SplitFrontmatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s frontmatter: %w", SkillFileName, err)
+	}
+
+	matcher, _ := ignore.Load(dir)
+	return &TokenEstimate{Body: EstimateTokens(body), References: estimateReferences(dir, matcher)}, nil
+}
+
+// estimateReferences estimates the tokens of each text file under references/.
+func estimateReferences(dir string, matcher *ignore.Matcher) map[string]int {
+	references := make(map[string]int)
+	for _, name := range resourceFiles(dir, matcher) {
+		if !strings.HasPrefix(name, "references/") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || !utf8.Valid(data) {
+			continue
+		}
+		references[name] = EstimateTokens(data)
+	}
+	return references
+}
+
+// Budget limits an estimated token count. A count above Warn is reported as a warning, and
+// one above Fail as an error. Zero disables a limit.
+type Budget struct {
+	Warn int `yaml:"warn,omitempty"`
+	Fail int `yaml:"fail,omitempty"`
+}
+
+// TokenBudgets are the token budgets of a skill, configured under "budgets" in .skr.yaml.
+type TokenBudgets struct {
+	// Body limits the SKILL.md body, which is loaded whenever the skill is activated.
+	Body Budget `yaml:"body,omitempty"`
+	// Reference limits each file under references/.
+	Reference Budget `yaml:"reference,omitempty"`
+	// Total limits the body and all reference files together.
+	Total Budget `yaml:"total,omitempty"`
+}
+
+// DefaultTokenBudgets warns when the SKILL.md body exceeds the 5000 tokens the Agent Skills
+// specification recommends.
+var DefaultTokenBudgets = TokenBudgets{Body: Budget{Warn: 5000}}
+
+// Merge returns b with the limits set in other replacing its own.
+func (b TokenBudgets) Merge(other TokenBudgets) TokenBudgets {
+	b.Body = b.Body.merge(other.Body)
+	b.Reference = b.Reference.merge(other.Reference)
+	b.Total = b.Total.merge(other.Total)
+	return b
+}
+
+func (b Budget) merge(other Budget) Budget {
+	if other.Warn != 0 {
+		b.Warn = other.Warn
+	}
+	if other.Fail != 0 {
+		b.Fail = other.Fail
+	}
+	return b
+}
+
+// Check reports the parts of the skill in dir whose estimate exceeds the budgets.
+func (b TokenBudgets) Check(dir string, estimate *TokenEstimate) Diagnostics {
+	var ds Diagnostics
+	file := filepath.Join(dir, SkillFileName)
+
+	b.Body.check(&ds, file, "the SKILL.md body", estimate.Body)
+
+	names := make([]string, 0, len(estimate.References))
+	for name := range estimate.References {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.Reference.check(&ds, filepath.Join(dir, filepath.FromSlash(name)), name, estimate.References[name])
+	}
+
+	b.Total.check(&ds, file, "the skill", estimate.Total())
+	return ds
+}
+
+// check adds a diagnostic to ds if tokens exceeds the budget.
+func (b Budget) check(ds *Diagnostics, file, what string, tokens int) {
+	var severity Severity
+	var limit int
+	switch {
+	case b.Fail > 0 && tokens > b.Fail:
+		severity, limit = SeverityError, b.Fail
+	case b.Warn > 0 && tokens > b.Warn:
+		severity, limit = SeverityWarning, b.Warn
+	default:
+		return
+	}
+	*ds = append(*ds, Diagnostic{
+		Rule:     RuleTokenBudget.ID,
+		Severity: severity,
+		File:     file,
+		Message:  fmt.Sprintf("%s is an estimated %d tokens, over the budget of %d", what, tokens, limit),
+	})
+}
@@ -0,0 +1,58 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(nil))
+	assert.Equal(t, 1, EstimateTokens([]byte("abc")))
+	assert.Equal(t, 2, EstimateTokens([]byte("abcde")))
+	// Characters are counted, not bytes.
+	assert.Equal(t, 1, EstimateTokens([]byte("ééé")))
+}
+
+func TestTokenBudgets_Merge(t *testing.T) {
+	merged := DefaultTokenBudgets.Merge(TokenBudgets{
+		Body:      Budget{Fail: 8000},
+		Reference: Budget{Warn: 100},
+	})
+	assert.Equal(t, TokenBudgets{
+		Body:      Budget{Warn: 5000, Fail: 8000},
+		Reference: Budget{Warn: 100},
+	}, merged)
+}
+
+func TestCheck_TokenBudgets(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "big")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "references"), 0755))
+	writeSkill(t, dir, "---\nname: big\ndescription: A skill with a lot of text to load.\nlicense: MIT\n---\n"+
+		"Read [the guide](references/guide.md).\n"+strings.Repeat("a", 400))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "references", "guide.md"), []byte(strings.Repeat("b", 80)), 0644))
+
+	estimate, err := EstimateSkillTokens(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 110, estimate.Body)
+	assert.Equal(t, map[string]int{"references/guide.md": 20}, estimate.References)
+	assert.Equal(t, 130, estimate.Total())
+
+	_, diags := Check(dir, WithTokenBudgets(TokenBudgets{
+		Body:      Budget{Warn: 50, Fail: 100},
+		Reference: Budget{Warn: 10},
+		Total:     Budget{Warn: 1000},
+	}))
+	assert.Equal(t, Diagnostics{
+		{Rule: "token-budget", Severity: SeverityError, File: filepath.Join(dir, SkillFileName), Message: "the SKILL.md body is an estimated 110 tokens, over the budget of 100"},
+		{Rule: "token-budget", Severity: SeverityWarning, File: filepath.Join(dir, "references", "guide.md"), Message: "references/guide.md is an estimated 20 tokens, over the budget of 10"},
+	}, diags)
+
+	// The default budgets leave small skills alone.
+	_, diags = Check(dir)
+	assert.Empty(t, diags)
+}
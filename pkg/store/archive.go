@@ -0,0 +1,224 @@
+package store
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// Save writes the given references, together with their manifests, configs and layers, to w
+// as an OCI image-layout tarball. Each reference is recorded in the archive index under the
+// org.opencontainers.image.ref.name annotation, so it must name a tag; a reference pinned to
+// a digest, as in repo:tag@sha256:..., is saved under its tag.
+func (s *Store) Save(ctx context.Context, w io.Writer, refs ...string) error {
+	if len(refs) == 0 {
+		return fmt.Errorf("no references to save")
+	}
+
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	index := ocispec.Index{MediaType: ocispec.MediaTypeImageIndex}
+	index.SchemaVersion = 2
+	blobs := make(map[digest.Digest]ocispec.Descriptor)
+	for _, ref := range refs {
+		name, pinned, err := archiveName(ref)
+		if err != nil {
+			return err
+		}
+		desc, err := s.backend.Resolve(ctx, pinned)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", ref, err)
+		}
+
+		entry := ocispec.Descriptor{
+			MediaType:   desc.MediaType,
+			Digest:      desc.Digest,
+			Size:        desc.Size,
+			Annotations: map[string]string{ocispec.AnnotationRefName: name},
+		}
+		index.Manifests = append(index.Manifests, entry)
+
+		if err := s.collectBlobs(ctx, desc, blobs); err != nil {
+			return fmt.Errorf("failed to collect content of %s: %w", ref, err)
+		}
+	}
+
+	layoutJSON, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	if err := writeArchiveFile(tw, ocispec.ImageLayoutFile, layoutJSON); err != nil {
+		return err
+	}
+	if err := writeArchiveFile(tw, ocispec.ImageIndexFile, indexJSON); err != nil {
+		return err
+	}
+
+	digests := make([]digest.Digest, 0, len(blobs))
+	for d := range blobs {
+		digests = append(digests, d)
+	}
+	sort.Slice(digests, func(i, j int) bool { return digests[i] < digests[j] })
+
+	if err := writeArchiveDir(tw, ocispec.ImageBlobsDir); err != nil {
+		return err
+	}
+	dirs := make(map[string]bool)
+	for _, d := range digests {
+		dir := ocispec.ImageBlobsDir + "/" + d.Algorithm().String()
+		if !dirs[dir] {
+			if err := writeArchiveDir(tw, dir); err != nil {
+				return err
+			}
+			dirs[dir] = true
+		}
+		if err := s.writeArchiveBlob(ctx, tw, dir+"/"+d.Encoded(), blobs[d]); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// archiveName returns the name ref is recorded under in an archive, and what to resolve it
+// by. Load only imports named artifacts, so a reference to a digest must also name a tag.
+func archiveName(ref string) (name, resolve string, err error) {
+	if _, err := digest.Parse(ref); err == nil {
+		return "", "", fmt.Errorf("cannot save %s: a tag is required, as the archive records artifacts by tag", ref)
+	}
+	parsed, err := ParseReference(ref)
+	if err != nil || parsed.Digest == "" {
+		return ref, ref, nil
+	}
+	if parsed.Tag == "" {
+		return "", "", fmt.Errorf("cannot save %s: a tag is required, as the archive records artifacts by tag", ref)
+	}
+	return Reference{Repository: parsed.Repository, Tag: parsed.Tag}.String(), parsed.Digest.String(), nil
+}
+
+// Load imports every tagged artifact from an OCI image-layout tarball at path and returns
+// the references that were added to the store.
+func (s *Store) Load(ctx context.Context, path string) ([]string, error) {
+	src, err := oci.NewFromTar(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layout archive: %w", err)
+	}
+
+	var tags []string
+	err = src.Tags(ctx, "", func(list []string) error {
+		tags = append(tags, list...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("archive %s contains no tagged artifacts", path)
+	}
+
+	unlock, err := s.lock.acquire(ctx, exclusiveLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	for _, tag := range tags {
+		if _, err := oras.Copy(ctx, src, tag, s.backend, tag, oras.DefaultCopyOptions); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", tag, err)
+		}
+	}
+	return tags, nil
+}
+
+// collectBlobs adds desc and everything it references to blobs.
+func (s *Store) collectBlobs(ctx context.Context, desc ocispec.Descriptor, blobs map[digest.Digest]ocispec.Descriptor) error {
+	if _, ok := blobs[desc.Digest]; ok {
+		return nil
+	}
+	blobs[desc.Digest] = desc
+
+	successors, err := content.Successors(ctx, s.backend, desc)
+	if err != nil {
+		return err
+	}
+	for _, successor := range successors {
+		if err := s.collectBlobs(ctx, successor, blobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeArchiveBlob copies a blob into the archive, verifying it against its descriptor.
+func (s *Store) writeArchiveBlob(ctx context.Context, tw *tar.Writer, name string, desc ocispec.Descriptor) error {
+	rc, err := s.backend.Fetch(ctx, desc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", desc.Digest, err)
+	}
+	defer rc.Close()
+
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     desc.Size,
+		Mode:     0644,
+		ModTime:  epoch,
+		Format:   tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	vr := content.NewVerifyReader(rc, desc)
+	if _, err := io.Copy(tw, vr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", desc.Digest, err)
+	}
+	if err := vr.Verify(); err != nil {
+		return fmt.Errorf("blob %s is corrupt (run 'skr system fsck'): %w", desc.Digest, err)
+	}
+	return nil
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, data []byte) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  epoch,
+		Format:   tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func writeArchiveDir(tw *tar.Writer, name string) error {
+	return tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0755,
+		ModTime:  epoch,
+		Format:   tar.FormatPAX,
+	})
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoad(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	src, err := New(t.TempDir())
+	require.NoError(t, err)
+	desc, err := src.Build(ctx, srcDir, "ghcr.io/org/test:v1", nil)
+	require.NoError(t, err)
+	_, err = src.Build(ctx, srcDir, "ghcr.io/org/other:v1", map[string]string{"a": "b"})
+	require.NoError(t, err)
+
+	archive := filepath.Join(t.TempDir(), "skills.tar")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	require.NoError(t, src.Save(ctx, f, "ghcr.io/org/test:v1"))
+	require.NoError(t, f.Close())
+
+	dst, err := New(t.TempDir())
+	require.NoError(t, err)
+	loaded, err := dst.Load(ctx, archive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ghcr.io/org/test:v1"}, loaded)
+
+	tags, err := dst.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ghcr.io/org/test:v1"}, tags)
+
+	got, err := dst.Resolve(ctx, "ghcr.io/org/test:v1")
+	require.NoError(t, err)
+	assert.Equal(t, desc.Digest, got.Digest)
+
+	report, err := dst.Verify(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, report.Healthy())
+	assert.Empty(t, report.Orphans)
+
+	err = src.Save(ctx, f, "ghcr.io/org/missing:v1")
+	assert.Error(t, err)
+
+	err = src.Save(ctx, f, desc.Digest.String())
+	assert.ErrorContains(t, err, "a tag is required")
+	err = src.Save(ctx, f, "ghcr.io/org/test@"+desc.Digest.String())
+	assert.ErrorContains(t, err, "a tag is required")
+
+	pinned := filepath.Join(t.TempDir(), "pinned.tar")
+	f, err = os.Create(pinned)
+	require.NoError(t, err)
+	require.NoError(t, src.Save(ctx, f, "ghcr.io/org/test:v1@"+desc.Digest.String()))
+	require.NoError(t, f.Close())
+	other, err := New(t.TempDir())
+	require.NoError(t, err)
+	loaded, err = other.Load(ctx, pinned)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ghcr.io/org/test:v1"}, loaded)
+}
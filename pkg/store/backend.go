@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+// ErrInvalidIndex is returned by Backend.Index when the index cannot be parsed.
+var ErrInvalidIndex = errors.New("invalid index")
+
+// Backend is the storage a Store keeps its blobs and tags in. Store serializes access
+// through its lock: anything that writes the index, such as pushing a manifest, tagging or
+// deleting, holds the exclusive lock. Backends only need to be safe for concurrent reads
+// and for concurrent pushes of blobs other than manifests.
+//
+// Backends follow the semantics of an OCI image layout: pushed manifests are recorded in
+// the index untagged, and deleting a manifest also deletes the untagged content it
+// references that nothing else does.
+type Backend interface {
+	content.Storage
+	content.Deleter
+	content.TagResolver
+	content.Untagger
+	registry.TagLister
+
+	// Init prepares empty storage. It is called once, under the exclusive lock, when the
+	// Store is created.
+	Init() error
+	// Reload re-reads state that another process may have changed. It is called whenever
+	// the store lock is freshly acquired.
+	Reload() error
+	// LockFile returns the path of the file used to coordinate access between processes,
+	// or an empty string if the storage is private to this process.
+	LockFile() string
+
+	// Blobs lists every blob, including those that nothing references.
+	Blobs(ctx context.Context) ([]BlobInfo, error)
+	// DeleteBlob removes a blob without checking whether anything references it.
+	DeleteBlob(ctx context.Context, d digest.Digest) error
+	// Index returns every manifest in the index, untagged ones included. Tagged manifests
+	// carry the tag in the org.opencontainers.image.ref.name annotation. Implementations
+	// should not depend on Reload having succeeded, so that Verify can run on a damaged store.
+	Index(ctx context.Context) (ocispec.Index, error)
+	// WriteIndex replaces the index.
+	WriteIndex(ctx context.Context, index ocispec.Index) error
+}
+
+// isManifest reports whether desc describes a manifest, which backends record in the index.
+func isManifest(desc ocispec.Descriptor) bool {
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex,
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json":
+		return true
+	}
+	return false
+}
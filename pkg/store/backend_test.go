@@ -0,0 +1,98 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackends runs the same operations against every backend.
+func TestBackends(t *testing.T) {
+	backends := map[string]func(t *testing.T) *Store{
+		"filesystem": func(t *testing.T) *Store {
+			st, err := New(t.TempDir())
+			require.NoError(t, err)
+			return st
+		},
+		"memory": func(t *testing.T) *Store {
+			st, err := NewMemory()
+			require.NoError(t, err)
+			return st
+		},
+	}
+
+	for name, newStore := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().Truncate(time.Second)
+			st := newStore(t)
+
+			buildAt(t, st, "skill:v1", now.Add(-48*time.Hour))
+			buildAt(t, st, "skill:v2", now)
+
+			// Resolve and List
+			desc, err := st.Resolve(ctx, "skill:v2")
+			require.NoError(t, err)
+			assert.Equal(t, ocispec.MediaTypeImageManifest, desc.MediaType)
+			byDigest, err := st.Resolve(ctx, desc.Digest.String())
+			require.NoError(t, err)
+			assert.Equal(t, desc.Digest, byDigest.Digest)
+			assert.Equal(t, []string{"skill:v1", "skill:v2"}, listTags(t, st))
+
+			found, err := st.Lookup(ctx, desc.Digest.Encoded()[:12])
+			require.NoError(t, err)
+			assert.Equal(t, desc.Digest, found.Digest)
+
+			// Tag
+			require.NoError(t, st.Tag(ctx, desc, "skill:latest"))
+			entries, err := st.Entries(ctx)
+			require.NoError(t, err)
+			require.Len(t, entries, 3)
+			assert.Equal(t, desc.Digest, entries[0].Digest) // skill:latest
+			assert.Equal(t, now.UTC(), entries[2].Created.UTC())
+
+			// An orphan blob is only removed by Prune
+			orphan := []byte("orphan")
+			orphanDesc := ocispec.Descriptor{MediaType: "application/octet-stream", Digest: digest.FromBytes(orphan), Size: int64(len(orphan))}
+			require.NoError(t, st.Push(ctx, orphanDesc, bytes.NewReader(orphan)))
+
+			report, err := st.Prune(ctx, WithOlderThan(24*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"skill:v1"}, report.Tags)
+			var pruned []digest.Digest
+			for _, blob := range report.Blobs {
+				pruned = append(pruned, blob.Digest)
+			}
+			assert.Contains(t, pruned, orphanDesc.Digest)
+			assert.Equal(t, []string{"skill:latest", "skill:v2"}, listTags(t, st))
+
+			exists, err := st.Exists(ctx, orphanDesc)
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			verify, err := st.Verify(ctx, false)
+			require.NoError(t, err)
+			assert.True(t, verify.Healthy(), "%v", verify.Problems)
+			assert.Empty(t, verify.Orphans)
+
+			// Deleting the manifest removes its tags and content
+			manifest, err := st.Manifest(ctx, desc)
+			require.NoError(t, err)
+			require.NoError(t, st.Delete(ctx, desc))
+			assert.Empty(t, listTags(t, st))
+			exists, err = st.Exists(ctx, manifest.Layers[0])
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			usage, err := st.DiskUsage(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, usage.Total)
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package store
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/andrewhowdencom/skr/pkg/git"
+	"github.com/andrewhowdencom/skr/pkg/skill"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// SourceDateEpochEnv is the environment variable used to pin the artifact creation time.
+// See https://reproducible-builds.org/specs/source-date-epoch/
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// epoch is the timestamp written to every tar entry, so that layer digests depend only on content.
+var epoch = time.Unix(0, 0).UTC()
+
+// ErrArtifactTooLarge is returned by Build when the layer exceeds the configured maximum size.
+var ErrArtifactTooLarge = errors.New("artifact exceeds maximum size")
+
+type buildOptions struct {
+	maxSize     int64
+	links       LinkPolicy
+	layered     bool
+	compression Compression
+}
+
+// BuildOption configures a call to Build.
+type BuildOption func(*buildOptions)
+
+// WithMaxSize limits the compressed size of the skill layer to n bytes. Zero means unlimited.
+func WithMaxSize(n int64) BuildOption {
+	return func(o *buildOptions) { o.maxSize = n }
+}
+
+// WithLayered splits the artifact into separate layers for SKILL.md (and other top-level
+// files), references/, scripts/ and assets/, so that unchanged directories are shared
+// between versions. The default is a single layer.
+func WithLayered(layered bool) BuildOption {
+	return func(o *buildOptions) { o.layered = layered }
+}
+
+// WithLinkPolicy sets how symbolic links in the source directory are packaged.
+// The default is LinksPreserve.
+func WithLinkPolicy(p LinkPolicy) BuildOption {
+	return func(o *buildOptions) { o.links = p }
+}
+
+// Build packages srcDir into a skill artifact, stores it and optionally tags it. The
+// estimated token counts of the skill are added to the manifest annotations.
+//
+// Builds are reproducible: entries are written in sorted order with normalized ownership,
+// permissions and timestamps, so identical sources always produce identical digests.
+// Layers are streamed to temporary files while they are hashed, so memory use does not
+// grow with the size of the skill.
+func (s *Store) Build(ctx context.Context, srcDir string, tag string, annotations map[string]string, opts ...BuildOption) (ocispec.Descriptor, error) {
+	options := newBuildOptions(opts)
+
+	created, err := sourceDate(srcDir)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	// 1. Create a tarball of each layer
+	entries, err := collect(srcDir, options.links)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to walk source directory: %w", err)
+	}
+
+	var layers []ocispec.Descriptor
+	var layerFiles []*os.File
+	var files []File
+	remaining := options.maxSize
+	for _, group := range groupLayers(entries, options.layered) {
+		if options.maxSize > 0 && remaining <= 0 {
+			return ocispec.Descriptor{}, fmt.Errorf("%w of %d bytes", ErrArtifactTooLarge, options.maxSize)
+		}
+
+		layerFile, err := os.CreateTemp("", "skr-layer-*")
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		defer os.Remove(layerFile.Name())
+		defer layerFile.Close()
+
+		layerDesc, layerInventory, err := writeLayer(layerFile, group, options.compression, remaining)
+		if err != nil {
+			if errors.Is(err, ErrArtifactTooLarge) {
+				return ocispec.Descriptor{}, fmt.Errorf("%w of %d bytes", ErrArtifactTooLarge, options.maxSize)
+			}
+			return ocispec.Descriptor{}, err
+		}
+		if options.maxSize > 0 {
+			remaining -= layerDesc.Size
+		}
+
+		layers = append(layers, layerDesc)
+		layerFiles = append(layerFiles, layerFile)
+		files = append(files, layerInventory...)
+	}
+
+	config, err := newConfig(srcDir, created, files)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	// Record the estimated token counts alongside the caller's annotations.
+	estimate, err := skill.EstimateSkillTokens(srcDir)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to estimate tokens: %w", err)
+	}
+	merged := estimate.Annotations()
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	annotations = merged
+
+	// 2. Push layers to store. The lock is only taken once the layers are on disk, so
+	// that other processes are not blocked while the skill is being compressed.
+	unlock, err := s.lock.acquire(ctx, exclusiveLock)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer unlock()
+
+	for i, layerDesc := range layers {
+		if _, err := layerFiles[i].Seek(0, io.SeekStart); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to rewind layer: %w", err)
+		}
+		err = s.pushBlob(ctx, layerDesc, layerFiles[i])
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to push layer: %w", err)
+		}
+	}
+
+	// 3. Create and push config
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	configDigest := digest.FromBytes(configBytes)
+	configDesc := ocispec.Descriptor{
+		MediaType: MediaTypeSkillConfig,
+		Digest:    configDigest,
+		Size:      int64(len(configBytes)),
+	}
+	err = s.pushBlob(ctx, configDesc, bytes.NewReader(configBytes))
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push config: %w", err)
+	}
+
+	// 4. Create and push Manifest
+	manifest := ocispec.Manifest{
+		Config:      configDesc,
+		Layers:      layers,
+		Annotations: annotations,
+	}
+	manifest.SchemaVersion = 2
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifestBytes),
+		Size:      int64(len(manifestBytes)),
+	}
+
+	err = s.pushBlob(ctx, manifestDesc, bytes.NewReader(manifestBytes))
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest: %w", err)
+	}
+
+	// 5. Tag the manifest
+	if tag != "" {
+		err = s.backend.Tag(ctx, manifestDesc, tag)
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to tag artifact: %w", err)
+		}
+	}
+
+	return manifestDesc, nil
+}
+
+// writeLayer streams a compressed tarball of the group's entries into w and returns its
+// descriptor and the inventory of packaged files.
+// If max is positive, writing stops with ErrArtifactTooLarge as soon as it is exceeded.
+func writeLayer(w io.Writer, group layerGroup, compression Compression, max int64) (ocispec.Descriptor, []File, error) {
+	digester := digest.Canonical.Digester()
+	counter := &limitWriter{w: io.MultiWriter(w, digester.Hash()), max: max}
+
+	cw, err := newCompressor(counter, compression)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	tw := tar.NewWriter(cw)
+
+	files, err := writeTar(tw, group.entries)
+	if err != nil {
+		if errors.Is(err, ErrArtifactTooLarge) {
+			return ocispec.Descriptor{}, nil, err
+		}
+		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to package source directory: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	if err := cw.Close(); err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+
+	return ocispec.Descriptor{
+		MediaType: group.mediaType + compression.suffix(),
+		Digest:    digester.Digest(),
+		Size:      counter.n,
+	}, files, nil
+}
+
+// limitWriter counts the bytes written through it and fails once max is exceeded.
+type limitWriter struct {
+	w   io.Writer
+	n   int64
+	max int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.max > 0 && l.n+int64(len(p)) > l.max {
+		return 0, fmt.Errorf("%w of %d bytes", ErrArtifactTooLarge, l.max)
+	}
+	n, err := l.w.Write(p)
+	l.n += int64(n)
+	return n, err
+}
+
+// writeTar writes entries to tw in a deterministic format, and returns the inventory of
+// the files written.
+func writeTar(tw *tar.Writer, entries []entry) ([]File, error) {
+	// Regular files already written, keyed by inode, so that hardlinks are stored once.
+	written := make(map[fileKey]File)
+	var files []File
+
+	for _, e := range entries {
+		header, err := tar.FileInfoHeader(e.info, e.linkname)
+		if err != nil {
+			return nil, err
+		}
+		normalizeHeader(header, e.info)
+		header.Name = filepath.ToSlash(e.name)
+		if e.info.IsDir() {
+			header.Name += "/"
+		}
+
+		isRegular := e.info.Mode().IsRegular()
+		var first File
+		var seen bool
+		key, linkable := hardlinkKey(e.info)
+		if isRegular && linkable {
+			if first, seen = written[key]; seen {
+				header.Typeflag = tar.TypeLink
+				header.Linkname = first.Path
+				header.Size = 0
+			}
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case seen:
+			// Hardlinks share the content of the first file
+			file := first
+			file.Path = header.Name
+			files = append(files, file)
+		case isRegular:
+			digester := digest.Canonical.Digester()
+			if err := copyFileTo(io.MultiWriter(tw, digester.Hash()), e.path); err != nil {
+				return nil, err
+			}
+			file := File{
+				Path:       header.Name,
+				Type:       FileTypeRegular,
+				Size:       header.Size,
+				Digest:     digester.Digest(),
+				Executable: header.Mode&0111 != 0,
+			}
+			if linkable {
+				written[key] = file
+			}
+			files = append(files, file)
+		case header.Typeflag == tar.TypeSymlink:
+			files = append(files, File{Path: header.Name, Type: FileTypeSymlink, Linkname: header.Linkname})
+		}
+	}
+	return files, nil
+}
+
+// normalizeHeader strips host-specific metadata from a tar header.
+func normalizeHeader(header *tar.Header, fi fs.FileInfo) {
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	header.ModTime = epoch
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.PAXRecords = nil
+	header.Format = tar.FormatUnknown
+
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		header.Mode = 0777
+	case fi.IsDir(), fi.Mode()&0111 != 0:
+		header.Mode = 0755
+	default:
+		header.Mode = 0644
+	}
+}
+
+func copyFileTo(w io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// sourceDate returns the creation time recorded in the artifact config.
+// It prefers $SOURCE_DATE_EPOCH, then the last git commit touching srcDir, and
+// finally falls back to the Unix epoch so that builds stay reproducible.
+func sourceDate(srcDir string) (time.Time, error) {
+	if value := os.Getenv(SourceDateEpochEnv); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s %q: %w", SourceDateEpochEnv, value, err)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	if t, err := git.LastCommitTime(srcDir); err == nil {
+		return t, nil
+	}
+
+	return epoch, nil
+}
@@ -0,0 +1,311 @@
+package store
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andrewhowdencom/skr/pkg/skill"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSkill(t *testing.T, dir string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "scripts"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "references"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: test\ndescription: A test skill\n---\n# Test\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "references", "guide.md"), []byte("# Guide\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scripts", "run.sh"), []byte("#!/bin/sh\necho hi\n"), 0700))
+}
+
+func TestBuild_Reproducible(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	first, err := New(t.TempDir())
+	require.NoError(t, err)
+	firstDesc, err := first.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	// Touch every file so that mtimes differ between builds
+	later := time.Now().Add(time.Hour)
+	err = filepath.Walk(srcDir, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, later, later)
+	})
+	require.NoError(t, err)
+
+	second, err := New(t.TempDir())
+	require.NoError(t, err)
+	secondDesc, err := second.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, firstDesc.Digest, secondDesc.Digest)
+}
+
+func TestBuild_ReproducibleAcrossCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	repo := t.TempDir()
+	srcDir := filepath.Join(repo, "skill")
+	writeSkill(t, srcDir)
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	gitRun("init", "-q")
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "skill")
+
+	build := func() ocispec.Descriptor {
+		t.Helper()
+		st, err := New(t.TempDir())
+		require.NoError(t, err)
+		desc, err := st.Build(ctx, srcDir, "test:v1", nil)
+		require.NoError(t, err)
+		return desc
+	}
+	before := build()
+
+	// A commit elsewhere in the repository must not change the artifact
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("# Skills\n"), 0644))
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "readme")
+
+	assert.Equal(t, before.Digest, build().Digest)
+}
+
+func TestBuild_SourceDateEpoch(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	a, err := st.Build(ctx, srcDir, "", nil)
+	require.NoError(t, err)
+
+	t.Setenv(SourceDateEpochEnv, "1800000000")
+	b, err := st.Build(ctx, srcDir, "", nil)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a.Digest, b.Digest, "config creation time should follow SOURCE_DATE_EPOCH")
+
+	t.Setenv(SourceDateEpochEnv, "not-a-number")
+	_, err = st.Build(ctx, srcDir, "", nil)
+	assert.Error(t, err)
+}
+
+func TestFiles_Ignore(t *testing.T) {
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "tests"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "tests", "fixture.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, ".skrignore"), []byte("tests/\n"), 0644))
+
+	files, err := Files(srcDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"SKILL.md",
+		"references",
+		filepath.Join("references", "guide.md"),
+		"scripts",
+		filepath.Join("scripts", "run.sh"),
+	}, files)
+}
+
+func TestBuild_MaxSize(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = st.Build(ctx, srcDir, "test:v1", nil, WithMaxSize(16))
+	assert.ErrorIs(t, err, ErrArtifactTooLarge)
+
+	_, err = st.Resolve(ctx, "test:v1")
+	assert.Error(t, err, "an oversized artifact must not be tagged")
+
+	desc, err := st.Build(ctx, srcDir, "test:v1", nil, WithMaxSize(1<<20))
+	require.NoError(t, err)
+	assert.NotEmpty(t, desc.Digest)
+}
+
+func TestBuild_Config(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: test\ndescription: A test skill\ndependencies:\n  - ghcr.io/org/dep:v1\nlicense: MIT\n---\n# Test\n"), 0644))
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+	desc, err := st.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	manifest, err := st.Manifest(ctx, desc)
+	require.NoError(t, err)
+	config, err := st.Config(ctx, manifest)
+	require.NoError(t, err)
+
+	assert.Equal(t, ConfigSchemaVersion, config.SchemaVersion)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), config.Created.UTC())
+	assert.Equal(t, "test", config.Name())
+	assert.Equal(t, "A test skill", config.Description())
+	assert.Equal(t, "MIT", config.Frontmatter["license"])
+	assert.Equal(t, []string{"ghcr.io/org/dep:v1"}, config.Dependencies)
+	assert.Equal(t, int64(len("# Test\n")), config.BodySize)
+	assert.Equal(t, "skr", config.Provenance.Builder)
+
+	// "# Test\n" and "# Guide\n" are estimated at two tokens each.
+	assert.Equal(t, "2", manifest.Annotations[skill.AnnotationTokensBody])
+	assert.Equal(t, "2", manifest.Annotations[skill.AnnotationTokensReferences])
+
+	require.Len(t, config.Files, 3)
+	assert.Equal(t, "SKILL.md", config.Files[0].Path)
+	assert.Equal(t, "references/guide.md", config.Files[1].Path)
+	assert.Equal(t, digest.FromString("# Guide\n"), config.Files[1].Digest)
+	assert.Equal(t, int64(len("# Guide\n")), config.Files[1].Size)
+	assert.False(t, config.Files[1].Executable)
+	assert.Equal(t, "scripts/run.sh", config.Files[2].Path)
+	assert.True(t, config.Files[2].Executable)
+
+	_, err = st.Config(ctx, ocispec.Manifest{Config: ocispec.Descriptor{MediaType: "application/vnd.unknown.config.v1+json"}})
+	assert.ErrorIs(t, err, ErrNotSkillConfig)
+}
+
+func TestBuild_FrontmatterKeys(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+	// YAML allows keys that are not strings, which JSON does not.
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: test\ndescription: A test skill\nx-extra:\n  1: foo\n  true: x\n  items:\n    - 2: y\n---\n"), 0644))
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+	desc, err := st.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	manifest, err := st.Manifest(ctx, desc)
+	require.NoError(t, err)
+	config, err := st.Config(ctx, manifest)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"1":     "foo",
+		"true":  "x",
+		"items": []any{map[string]any{"2": "y"}},
+	}, config.Frontmatter["x-extra"])
+}
+
+func TestBuild_Layered(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	layers := func(desc ocispec.Descriptor) []ocispec.Descriptor {
+		manifest, err := st.Manifest(ctx, desc)
+		require.NoError(t, err)
+		return manifest.Layers
+	}
+
+	single, err := st.Build(ctx, srcDir, "", nil)
+	require.NoError(t, err)
+	require.Len(t, layers(single), 1)
+	assert.Equal(t, MediaTypeSkillLayer, layers(single)[0].MediaType)
+
+	first, err := st.Build(ctx, srcDir, "", nil, WithLayered(true))
+	require.NoError(t, err)
+	firstLayers := layers(first)
+	require.Len(t, firstLayers, 3)
+	assert.Equal(t, MediaTypeSkillFileLayer, firstLayers[0].MediaType)
+	assert.Equal(t, MediaTypeReferencesLayer, firstLayers[1].MediaType)
+	assert.Equal(t, MediaTypeScriptsLayer, firstLayers[2].MediaType)
+
+	// Editing SKILL.md only changes its own layer
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "SKILL.md"), []byte("---\nname: test\ndescription: Changed\n---\n# Test\n"), 0644))
+	second, err := st.Build(ctx, srcDir, "", nil, WithLayered(true))
+	require.NoError(t, err)
+	secondLayers := layers(second)
+	require.Len(t, secondLayers, 3)
+	assert.NotEqual(t, firstLayers[0].Digest, secondLayers[0].Digest)
+	assert.Equal(t, firstLayers[1].Digest, secondLayers[1].Digest)
+	assert.Equal(t, firstLayers[2].Digest, secondLayers[2].Digest)
+
+	_, err = st.Build(ctx, srcDir, "", nil, WithLayered(true), WithMaxSize(200))
+	assert.ErrorIs(t, err, ErrArtifactTooLarge)
+}
+
+func TestBuild_Compression(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	tests := []struct {
+		compression Compression
+		mediaType   string
+	}{
+		{CompressionGzip, "application/vnd.agentskills.skill.layer.v1+tar+gzip"},
+		{CompressionZstd, "application/vnd.agentskills.skill.layer.v1+tar+zstd"},
+		{CompressionNone, "application/vnd.agentskills.skill.layer.v1+tar"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.compression), func(t *testing.T) {
+			var digests []digest.Digest
+			for range 2 {
+				st, err := New(t.TempDir())
+				require.NoError(t, err)
+				desc, err := st.Build(ctx, srcDir, "", nil, WithCompression(tt.compression))
+				require.NoError(t, err)
+
+				manifest, err := st.Manifest(ctx, desc)
+				require.NoError(t, err)
+				require.Len(t, manifest.Layers, 1)
+				assert.Equal(t, tt.mediaType, manifest.Layers[0].MediaType)
+				assert.True(t, IsSkillLayer(manifest.Layers[0].MediaType))
+				digests = append(digests, desc.Digest)
+			}
+			assert.Equal(t, digests[0], digests[1], "builds should be reproducible")
+		})
+	}
+
+	_, err := ParseCompression("brotli")
+	assert.Error(t, err)
+	c, err := ParseCompression("")
+	require.NoError(t, err)
+	assert.Equal(t, CompressionGzip, c)
+	assert.False(t, IsSkillLayer("application/vnd.oci.image.layer.v1.tar+zstd"))
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm used to compress skill layers.
+type Compression string
+
+const (
+	// CompressionGzip produces +tar+gzip layers. It is the default and can be read by every version of skr.
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd produces +tar+zstd layers, which are smaller and faster to unpack.
+	CompressionZstd Compression = "zstd"
+	// CompressionNone produces uncompressed +tar layers.
+	CompressionNone Compression = "none"
+)
+
+// ParseCompression parses a --compression flag value. An empty value selects CompressionGzip.
+func ParseCompression(value string) (Compression, error) {
+	switch Compression(value) {
+	case "", CompressionGzip:
+		return CompressionGzip, nil
+	case CompressionZstd, CompressionNone:
+		return Compression(value), nil
+	}
+	return "", fmt.Errorf("invalid compression %q: must be gzip, zstd or none", value)
+}
+
+// WithCompression sets how skill layers are compressed. The default is CompressionGzip.
+func WithCompression(c Compression) BuildOption {
+	return func(o *buildOptions) { o.compression = c }
+}
+
+// suffix returns the media type suffix for the compression.
+func (c Compression) suffix() string {
+	if c == CompressionNone {
+		return ""
+	}
+	return "+" + string(c)
+}
+
+// LayerCompression returns the compression of a skill layer media type, or false if
+// mediaType is not a skill layer.
+func LayerCompression(mediaType string) (Compression, bool) {
+	base, c := mediaType, CompressionNone
+	for _, candidate := range []Compression{CompressionGzip, CompressionZstd} {
+		if trimmed, ok := strings.CutSuffix(mediaType, candidate.suffix()); ok {
+			base, c = trimmed, candidate
+			break
+		}
+	}
+
+	switch base {
+	case mediaTypeSkillLayerTar, mediaTypeSkillFileLayerTar:
+		return c, true
+	}
+	for _, d := range layerDirs {
+		if d.mediaType == base {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// IsSkillLayer reports whether mediaType is one of the layer media types written by Build.
+func IsSkillLayer(mediaType string) bool {
+	_, ok := LayerCompression(mediaType)
+	return ok
+}
+
+// NewLayerReader returns a reader for the tar stream of a skill layer with the given media type.
+func NewLayerReader(r io.Reader, mediaType string) (io.ReadCloser, error) {
+	c, ok := LayerCompression(mediaType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported layer media type %q", mediaType)
+	}
+
+	switch c {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
+
+// newCompressor wraps w in a writer for the compression.
+func newCompressor(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		// A single encoder goroutine keeps the output identical between builds.
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", c)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
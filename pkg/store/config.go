@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andrewhowdencom/skr/pkg/git"
+	"github.com/andrewhowdencom/skr/pkg/skill"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSchemaVersion is the version of the config schema written by Build.
+// Artifacts built by older versions of skr have a config with only a creation time,
+// which decodes with a SchemaVersion of 0.
+const ConfigSchemaVersion = 1
+
+// ErrNotSkillConfig is returned by Config when a manifest does not reference a skill config.
+var ErrNotSkillConfig = errors.New("manifest does not have a skill config")
+
+// File types recorded in the config file inventory.
+const (
+	FileTypeRegular = "file"
+	FileTypeSymlink = "symlink"
+)
+
+// Config is the config blob of a skill artifact, stored under MediaTypeSkillConfig.
+type Config struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Created       time.Time `json:"created"`
+
+	// Frontmatter is the full parsed YAML frontmatter of SKILL.md.
+	Frontmatter map[string]any `json:"frontmatter,omitempty"`
+	// Dependencies are the references of the skills this skill depends on.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// BodySize is the size in bytes of the SKILL.md body following the frontmatter.
+	BodySize int64 `json:"bodySize"`
+	// Files is the inventory of packaged files, in layer order.
+	Files []File `json:"files,omitempty"`
+
+	Provenance Provenance `json:"provenance"`
+}
+
+// File describes a file packaged in a skill layer.
+type File struct {
+	Path       string        `json:"path"`
+	Type       string        `json:"type"`
+	Size       int64         `json:"size,omitempty"`
+	Digest     digest.Digest `json:"digest,omitempty"`
+	Executable bool          `json:"executable,omitempty"`
+	Linkname   string        `json:"linkname,omitempty"`
+}
+
+// Provenance records where an artifact was built from.
+type Provenance struct {
+	Builder  string `json:"builder"`
+	Source   string `json:"source,omitempty"`
+	Revision string `json:"revision,omitempty"`
+}
+
+// Name returns the skill name from the frontmatter.
+func (c *Config) Name() string {
+	return c.frontmatterString("name")
+}
+
+// Description returns the skill description from the frontmatter.
+func (c *Config) Description() string {
+	return c.frontmatterString("description")
+}
+
+// Skill decodes the frontmatter into the skill model.
+func (c *Config) Skill() (*skill.Skill, error) {
+	data, err := yaml.Marshal(c.Frontmatter)
+	if err != nil {
+		return nil, err
+	}
+	var s skill.Skill
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode frontmatter: %w", err)
+	}
+	return &s, nil
+}
+
+func (c *Config) frontmatterString(key string) string {
+	value, _ := c.Frontmatter[key].(string)
+	return value
+}
+
+// newConfig describes the skill in srcDir for the artifact config.
+func newConfig(srcDir string, created time.Time, files []File) (*Config, error) {
+	content, err := os.ReadFile(filepath.Join(srcDir, skill.SkillFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", skill.SkillFileName, err)
+	}
+
+	rawFrontmatter, body, err := skill.SplitFrontmatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s frontmatter: %w", skill.SkillFileName, err)
+	}
+
+	var frontmatter map[string]any
+	if err := yaml.Unmarshal(rawFrontmatter, &frontmatter); err != nil {
+		return nil, fmt.Errorf("failed to parse %s frontmatter: %w", skill.SkillFileName, err)
+	}
+	for key, value := range frontmatter {
+		frontmatter[key] = stringKeys(value)
+	}
+	var s skill.Skill
+	if err := yaml.Unmarshal(rawFrontmatter, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s frontmatter: %w", skill.SkillFileName, err)
+	}
+
+	config := &Config{
+		SchemaVersion: ConfigSchemaVersion,
+		Created:       created,
+		Frontmatter:   frontmatter,
+		Dependencies:  s.Dependencies,
+		BodySize:      int64(len(body)),
+		Files:         files,
+		Provenance:    Provenance{Builder: "skr"},
+	}
+	if source, err := git.RemoteURL(srcDir); err == nil {
+		config.Provenance.Source = source
+	}
+	if revision, err := git.Revision(srcDir); err == nil {
+		config.Provenance.Revision = revision
+	}
+
+	return config, nil
+}
+
+// stringKeys converts the keys of nested YAML maps, which may be numbers or booleans as in
+// "1: foo", to strings, so that the frontmatter can be encoded as JSON.
+func stringKeys(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return m
+	case map[string]any:
+		for key, item := range v {
+			v[key] = stringKeys(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	}
+	return value
+}
+
+// Config fetches and decodes the skill config referenced by manifest.
+// It returns ErrNotSkillConfig if the manifest has a different kind of config.
+func (s *Store) Config(ctx context.Context, manifest ocispec.Manifest) (*Config, error) {
+	if manifest.Config.MediaType != MediaTypeSkillConfig {
+		return nil, ErrNotSkillConfig
+	}
+
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var config Config
+	if err := s.readJSON(ctx, manifest.Config, &config); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if config.SchemaVersion > ConfigSchemaVersion {
+		return nil, fmt.Errorf("config schema version %d is not supported (newest supported is %d); upgrade skr", config.SchemaVersion, ConfigSchemaVersion)
+	}
+	return &config, nil
+}
+
+// Manifest fetches and decodes the image manifest described by desc.
+func (s *Store) Manifest(ctx context.Context, desc ocispec.Descriptor) (ocispec.Manifest, error) {
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return ocispec.Manifest{}, err
+	}
+	defer unlock()
+
+	var manifest ocispec.Manifest
+	if err := s.readJSON(ctx, desc, &manifest); err != nil {
+		return ocispec.Manifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return manifest, nil
+}
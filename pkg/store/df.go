@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// DiskUsage reports how the store uses disk space. All sizes are in bytes and count each
+// blob once, however many tags reference it.
+type DiskUsage struct {
+	// Blobs is the number of blobs in the store.
+	Blobs int `json:"blobs"`
+	// Total is the size of every blob in the store.
+	Total int64 `json:"totalBytes"`
+	// Reachable is the size of the blobs referenced by at least one tag.
+	Reachable int64 `json:"reachableBytes"`
+	// Reclaimable is the size of the blobs that Prune would delete.
+	Reclaimable int64 `json:"reclaimableBytes"`
+	// Shared is the size of the blobs referenced by more than one tag.
+	Shared int64 `json:"sharedBytes"`
+	// Extracted is the size of the extraction cache (see ExtractDir), which is not part of
+	// Total.
+	Extracted int64 `json:"extractedBytes"`
+	// Repositories breaks the reachable blobs down by repository, largest first.
+	Repositories []RepositoryUsage `json:"repositories"`
+}
+
+// RepositoryUsage is the disk usage of the tags of a single repository.
+type RepositoryUsage struct {
+	Repository string `json:"repository"`
+	Tags       int    `json:"tags"`
+	// Size is the size of the blobs referenced by the repository's tags.
+	Size int64 `json:"bytes"`
+	// Shared is the part of Size also referenced by other repositories.
+	Shared int64 `json:"sharedBytes"`
+}
+
+// DiskUsage measures the blobs in the store and attributes them to the tags that reference them,
+// and measures the extraction cache. Tags whose manifest cannot be read are left out, as in
+// Entries.
+func (s *Store) DiskUsage(ctx context.Context) (*DiskUsage, error) {
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	blobs, err := s.backend.Blobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[digest.Digest]int64, len(blobs))
+	usage := &DiskUsage{Blobs: len(blobs)}
+	for _, blob := range blobs {
+		sizes[blob.Digest] = blob.Size
+		usage.Total += blob.Size
+	}
+
+	tagged, err := s.taggedManifests(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to traverse tags: %w", err)
+	}
+
+	// Count the tags and repositories referencing each blob
+	tagRefs := make(map[digest.Digest]int)
+	repoRefs := make(map[digest.Digest]map[string]bool)
+	repos := make(map[string]*RepositoryUsage)
+	for _, t := range tagged {
+		repo, ok := repos[t.repo]
+		if !ok {
+			repo = &RepositoryUsage{Repository: t.repo}
+			repos[t.repo] = repo
+		}
+		repo.Tags++
+
+		seen := make(map[digest.Digest]bool)
+		for _, d := range t.blobs() {
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+			tagRefs[d]++
+			if repoRefs[d] == nil {
+				repoRefs[d] = make(map[string]bool)
+			}
+			repoRefs[d][t.repo] = true
+		}
+	}
+
+	for d, count := range tagRefs {
+		size := sizes[d] // zero if the blob is missing
+		usage.Reachable += size
+		if count > 1 {
+			usage.Shared += size
+		}
+		for name := range repoRefs[d] {
+			repos[name].Size += size
+			if len(repoRefs[d]) > 1 {
+				repos[name].Shared += size
+			}
+		}
+	}
+	usage.Reclaimable = usage.Total - usage.Reachable
+
+	if usage.Extracted, err = s.extractedSize(); err != nil {
+		return nil, err
+	}
+
+	usage.Repositories = make([]RepositoryUsage, 0, len(repos))
+	for _, repo := range repos {
+		usage.Repositories = append(usage.Repositories, *repo)
+	}
+	sort.Slice(usage.Repositories, func(i, j int) bool {
+		a, b := usage.Repositories[i], usage.Repositories[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Repository < b.Repository
+	})
+
+	return usage, nil
+}
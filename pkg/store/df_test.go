@@ -0,0 +1,61 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskUsage(t *testing.T) {
+	ctx := context.Background()
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+	_, err = st.Build(ctx, srcDir, "ghcr.io/org/one:v1", nil)
+	require.NoError(t, err)
+	_, err = st.Build(ctx, srcDir, "ghcr.io/org/one:v2", nil)
+	require.NoError(t, err)
+	_, err = st.Build(ctx, srcDir, "ghcr.io/org/two:v1", nil, WithLayered(true))
+	require.NoError(t, err)
+
+	// A blob that no tag references, e.g. left behind by an interrupted pull
+	orphan := []byte("orphaned layer")
+	require.NoError(t, st.Push(ctx, ocispec.Descriptor{MediaType: MediaTypeSkillLayer, Digest: digest.FromBytes(orphan), Size: int64(len(orphan))}, bytes.NewReader(orphan)))
+
+	// A cached extraction, as written by installs
+	cached := st.ExtractDir(digest.FromBytes(orphan))
+	require.NoError(t, os.MkdirAll(cached, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cached, "SKILL.md"), []byte("cached"), 0644))
+
+	usage, err := st.DiskUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("cached")), usage.Extracted)
+
+	assert.Equal(t, usage.Total, usage.Reachable+usage.Reclaimable)
+	assert.Equal(t, int64(len(orphan)), usage.Reclaimable)
+	assert.Positive(t, usage.Shared)
+
+	require.Len(t, usage.Repositories, 2)
+	byName := make(map[string]RepositoryUsage)
+	for _, repo := range usage.Repositories {
+		byName[repo.Repository] = repo
+	}
+	assert.Equal(t, 2, byName["ghcr.io/org/one"].Tags)
+	assert.Equal(t, 1, byName["ghcr.io/org/two"].Tags)
+	// Both repositories are built from the same SKILL.md, so they share its config blob
+	assert.Positive(t, byName["ghcr.io/org/one"].Shared)
+	assert.Equal(t, usage.Reachable, byName["ghcr.io/org/one"].Size+byName["ghcr.io/org/two"].Size-byName["ghcr.io/org/one"].Shared)
+
+	report, err := st.Prune(ctx, WithDryRun())
+	require.NoError(t, err)
+	assert.Equal(t, report.Reclaimed, usage.Reclaimable)
+}
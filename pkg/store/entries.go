@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Entry describes a tagged artifact in the store.
+type Entry struct {
+	Repository string `json:"repository"`
+	// Tag is empty for references stored without one, e.g. localhost:5000/skill.
+	Tag    string        `json:"tag,omitempty"`
+	Digest digest.Digest `json:"digest"`
+	// Size is the total size of the manifest, config and layers.
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+
+	// Descriptor is the descriptor of the manifest.
+	Descriptor ocispec.Descriptor `json:"-"`
+}
+
+// Reference returns the reference the entry is tagged as.
+func (e Entry) Reference() string {
+	return Reference{Repository: e.Repository, Tag: e.Tag}.String()
+}
+
+type entriesOptions struct {
+	repository string
+}
+
+// EntriesOption configures a call to Entries.
+type EntriesOption func(*entriesOptions)
+
+// WithRepository limits Entries to the tags of a single repository.
+func WithRepository(repository string) EntriesOption {
+	return func(o *entriesOptions) { o.repository = repository }
+}
+
+// Entries returns every tagged artifact in the store, sorted by repository and tag. Tags
+// whose manifest cannot be read are left out, so that one damaged artifact does not hide
+// the others; Verify reports them.
+func (s *Store) Entries(ctx context.Context, opts ...EntriesOption) ([]Entry, error) {
+	var options entriesOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tagged, err := s.taggedManifests(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, t := range tagged {
+		if options.repository != "" && t.repo != options.repository {
+			continue
+		}
+		entries = append(entries, Entry{
+			Repository: t.repo,
+			Tag:        t.tag,
+			Digest:     t.desc.Digest,
+			Size:       t.size(),
+			Created:    t.created,
+			Descriptor: t.desc,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Repository != entries[j].Repository {
+			return entries[i].Repository < entries[j].Repository
+		}
+		return entries[i].Tag < entries[j].Tag
+	})
+	return entries, nil
+}
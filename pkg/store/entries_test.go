@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntries(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	buildAt(t, st, "one:v2", created)
+	buildAt(t, st, "one:v1", created)
+	buildAt(t, st, "localhost:5000/skill:v1", created)
+	buildAt(t, st, "localhost:5000/skill", created)
+
+	entries, err := st.Entries(ctx)
+	require.NoError(t, err)
+
+	var refs []string
+	for _, e := range entries {
+		refs = append(refs, e.Reference())
+		assert.Equal(t, created, e.Created)
+		assert.Equal(t, e.Descriptor.Digest, e.Digest)
+		assert.Greater(t, e.Size, e.Descriptor.Size, "size includes config and layers")
+	}
+	assert.Equal(t, []string{"localhost:5000/skill", "localhost:5000/skill:v1", "one:v1", "one:v2"}, refs)
+	assert.Equal(t, "localhost:5000/skill", entries[0].Repository)
+	assert.Empty(t, entries[0].Tag)
+
+	t.Run("Repository", func(t *testing.T) {
+		entries, err := st.Entries(ctx, WithRepository("localhost:5000/skill"))
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "v1", entries[1].Tag)
+
+		entries, err = st.Entries(ctx, WithRepository("localhost"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestEntries_BrokenTag(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	dir := t.TempDir()
+
+	st, err := New(dir)
+	require.NoError(t, err)
+	buildAt(t, st, "one:v1", created)
+	buildAt(t, st, "two:v1", created)
+
+	// Lose the manifest of one tag, as an interrupted write or a damaged disk might.
+	broken, err := st.Resolve(ctx, "two:v1")
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, "blobs", "sha256", broken.Digest.Encoded())))
+
+	entries, err := st.Entries(ctx)
+	require.NoError(t, err, "one damaged tag must not hide the others")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "one:v1", entries[0].Reference())
+
+	_, err = st.DiskUsage(ctx)
+	assert.NoError(t, err)
+
+	// Prune must not decide what to delete from a partial view.
+	_, err = st.Prune(ctx)
+	assert.ErrorContains(t, err, "failed to read manifest for two:v1")
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// extractedDirName is the directory of a filesystem store caching extracted layers.
+const extractedDirName = "extracted"
+
+// ExtractDir returns the directory caching the extracted content of the layer d, or an
+// empty string if the store has no extraction cache (e.g. because it is kept in memory).
+//
+// The cache is populated by installs; entries whose layer is no longer in the store are
+// removed by Prune.
+func (s *Store) ExtractDir(d digest.Digest) string {
+	fs, ok := s.backend.(*FilesystemBackend)
+	if !ok {
+		return ""
+	}
+	return filepath.Join(fs.path, extractedDirName, d.Algorithm().String(), d.Encoded())
+}
+
+// RLock takes the shared store lock until the returned function is called, e.g. to
+// keep Prune from removing cached content while it is being read.
+func (s *Store) RLock(ctx context.Context) (func(), error) {
+	return s.lock.acquire(ctx, sharedLock)
+}
+
+// pruneExtracted removes cached extractions whose layer is no longer in the store,
+// along with any left behind by interrupted installs.
+func (s *Store) pruneExtracted(ctx context.Context) error {
+	fs, ok := s.backend.(*FilesystemBackend)
+	if !ok {
+		return nil
+	}
+
+	dir := filepath.Join(fs.path, extractedDirName, digest.SHA256.String())
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read extraction cache: %w", err)
+	}
+
+	for _, entry := range entries {
+		d := digest.NewDigestFromEncoded(digest.SHA256, entry.Name())
+		if d.Validate() == nil {
+			exists, err := s.backend.Exists(ctx, ocispec.Descriptor{Digest: d})
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove cached extraction %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// extractedSize returns the size of the files in the extraction cache, or zero if the store
+// has none. Files are counted once however many installs hardlink them.
+func (s *Store) extractedSize() (int64, error) {
+	fs, ok := s.backend.(*FilesystemBackend)
+	if !ok {
+		return 0, nil
+	}
+
+	var size int64
+	err := filepath.WalkDir(filepath.Join(fs.path, extractedDirName), func(path string, d os.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure extraction cache: %w", err)
+	}
+	return size, nil
+}
+
+// verifyExtracted compares the regular files of each cached extraction with the layer it was
+// extracted from, and returns a problem for each extraction that differs. Extractions of
+// layers that are damaged, or that no manifest references, are left to Prune.
+func (s *Store) verifyExtracted(ctx context.Context, layers map[digest.Digest]ocispec.Descriptor, blobs map[digest.Digest]blobState) ([]Problem, error) {
+	fs, ok := s.backend.(*FilesystemBackend)
+	if !ok {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(filepath.Join(fs.path, extractedDirName, digest.SHA256.String()))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extraction cache: %w", err)
+	}
+
+	var problems []Problem
+	for _, entry := range entries {
+		d := digest.NewDigestFromEncoded(digest.SHA256, entry.Name())
+		layer, ok := layers[d]
+		if d.Validate() != nil || !ok || !blobs[d].valid {
+			continue
+		}
+		detail, err := s.compareExtracted(ctx, layer, s.ExtractDir(d))
+		if err != nil {
+			return nil, err
+		}
+		if detail != "" {
+			problems = append(problems, Problem{Kind: ProblemCorruptExtraction, Digest: d, Detail: detail})
+		}
+	}
+	return problems, nil
+}
+
+// compareExtracted hashes the regular files of layer and the same files in dir, and
+// describes the first that differs, or returns "" if none does.
+func (s *Store) compareExtracted(ctx context.Context, layer ocispec.Descriptor, dir string) (string, error) {
+	rc, err := s.backend.Fetch(ctx, layer)
+	if err != nil {
+		return "", fmt.Errorf("failed to read layer %s: %w", layer.Digest, err)
+	}
+	defer rc.Close()
+	lr, err := NewLayerReader(rc, layer.MediaType)
+	if err != nil {
+		return "", fmt.Errorf("failed to read layer %s: %w", layer.Digest, err)
+	}
+	defer lr.Close()
+
+	tr := tar.NewReader(lr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read layer %s: %w", layer.Digest, err)
+		}
+		if header.Typeflag != tar.TypeReg || !filepath.IsLocal(header.Name) {
+			continue
+		}
+
+		expected, err := digest.SHA256.FromReader(tr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read layer %s: %w", layer.Digest, err)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+			return fmt.Sprintf("%s is missing", header.Name), nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		actual, err := digest.SHA256.FromReader(f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		if actual != expected {
+			return fmt.Sprintf("%s does not match the layer", header.Name), nil
+		}
+	}
+}
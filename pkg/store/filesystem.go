@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// FilesystemBackend stores skills in an OCI image layout on disk.
+type FilesystemBackend struct {
+	path string
+	oci  *oci.Store
+	// storage reads blobs directly, so that they can be fetched even when the index
+	// cannot be loaded.
+	storage *oci.Storage
+}
+
+// interface guard
+var _ Backend = &FilesystemBackend{}
+
+// NewFilesystemBackend returns a backend storing an OCI image layout in path, creating
+// the directory if needed.
+func NewFilesystemBackend(path string) (*FilesystemBackend, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	storage, err := oci.NewStorage(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FilesystemBackend{path: path, storage: storage}, nil
+}
+
+// Path returns the directory holding the OCI image layout.
+func (b *FilesystemBackend) Path() string {
+	return b.path
+}
+
+// Init writes the OCI layout files if the directory does not hold a layout yet.
+// Existing layouts are loaded by Reload instead, so that a corrupted index does not
+// prevent Verify from running.
+func (b *FilesystemBackend) Init() error {
+	if _, err := os.Stat(filepath.Join(b.path, ocispec.ImageLayoutFile)); os.IsNotExist(err) {
+		return b.Reload()
+	}
+	return nil
+}
+
+// Reload re-reads the OCI index, picking up tags written by other processes.
+func (b *FilesystemBackend) Reload() error {
+	ociStore, err := oci.New(b.path)
+	if err != nil {
+		return fmt.Errorf("failed to load OCI store (run 'skr system fsck'): %w", err)
+	}
+	b.oci = ociStore
+	return nil
+}
+
+// LockFile returns the lock file inside the layout directory.
+func (b *FilesystemBackend) LockFile() string {
+	return filepath.Join(b.path, LockFileName)
+}
+
+func (b *FilesystemBackend) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	return b.storage.Fetch(ctx, target)
+}
+
+func (b *FilesystemBackend) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	return b.storage.Exists(ctx, target)
+}
+
+func (b *FilesystemBackend) Push(ctx context.Context, expected ocispec.Descriptor, r io.Reader) error {
+	return b.oci.Push(ctx, expected, r)
+}
+
+func (b *FilesystemBackend) Delete(ctx context.Context, target ocispec.Descriptor) error {
+	return b.oci.Delete(ctx, target)
+}
+
+func (b *FilesystemBackend) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	return b.oci.Tag(ctx, desc, reference)
+}
+
+func (b *FilesystemBackend) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return b.oci.Resolve(ctx, reference)
+}
+
+func (b *FilesystemBackend) Untag(ctx context.Context, reference string) error {
+	return b.oci.Untag(ctx, reference)
+}
+
+func (b *FilesystemBackend) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	return b.oci.Tags(ctx, last, fn)
+}
+
+// Blobs lists every file under blobs/sha256 with its size on disk.
+func (b *FilesystemBackend) Blobs(ctx context.Context) ([]BlobInfo, error) {
+	blobsDir := filepath.Join(b.path, "blobs", "sha256")
+	entries, err := os.ReadDir(blobsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blobs directory: %w", err)
+	}
+
+	var blobs []BlobInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, BlobInfo{
+			Digest:   digest.NewDigestFromEncoded(digest.SHA256, entry.Name()),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+	return blobs, nil
+}
+
+// DeleteBlob removes the blob file. Blobs that do not exist are ignored.
+func (b *FilesystemBackend) DeleteBlob(ctx context.Context, d digest.Digest) error {
+	path := b.blobPath(d)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove blob %s: %w", path, err)
+	}
+	return nil
+}
+
+// Index reads index.json directly, without loading the manifests it lists.
+func (b *FilesystemBackend) Index(ctx context.Context) (ocispec.Index, error) {
+	var index ocispec.Index
+	data, err := os.ReadFile(filepath.Join(b.path, ocispec.ImageIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return index, fmt.Errorf("failed to read index: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+	}
+	return index, nil
+}
+
+// WriteIndex atomically replaces index.json. The change is picked up by the next Reload.
+func (b *FilesystemBackend) WriteIndex(ctx context.Context, index ocispec.Index) error {
+	index.SchemaVersion = 2
+	index.MediaType = ocispec.MediaTypeImageIndex
+	if index.Manifests == nil {
+		index.Manifests = []ocispec.Descriptor{}
+	}
+	return writeFileAtomic(filepath.Join(b.path, ocispec.ImageIndexFile), index)
+}
+
+func (b *FilesystemBackend) blobPath(d digest.Digest) string {
+	return filepath.Join(b.path, "blobs", d.Algorithm().String(), d.Encoded())
+}
+
+// writeFileAtomic writes v as JSON to path via a temporary file and rename.
+func writeFileAtomic(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Uncompressed media types of skill layers. Build appends a suffix for the compression,
+// e.g. +gzip or +zstd.
+const (
+	mediaTypeSkillLayerTar      = "application/vnd.agentskills.skill.layer.v1+tar"
+	mediaTypeSkillFileLayerTar  = "application/vnd.agentskills.skill.layer.skill.v1+tar"
+	mediaTypeReferencesLayerTar = "application/vnd.agentskills.skill.layer.references.v1+tar"
+	mediaTypeScriptsLayerTar    = "application/vnd.agentskills.skill.layer.scripts.v1+tar"
+	mediaTypeAssetsLayerTar     = "application/vnd.agentskills.skill.layer.assets.v1+tar"
+)
+
+// Media types of the gzip-compressed layers of a layered artifact (see WithLayered).
+// SKILL.md and any other files outside references/, scripts/ and assets/ are stored in
+// the skill file layer.
+const (
+	MediaTypeSkillFileLayer  = mediaTypeSkillFileLayerTar + "+gzip"
+	MediaTypeReferencesLayer = mediaTypeReferencesLayerTar + "+gzip"
+	MediaTypeScriptsLayer    = mediaTypeScriptsLayerTar + "+gzip"
+	MediaTypeAssetsLayer     = mediaTypeAssetsLayerTar + "+gzip"
+)
+
+// layerDirs maps the top-level directories that get their own layer to its uncompressed
+// media type, in the order the layers are written.
+var layerDirs = []struct {
+	dir       string
+	mediaType string
+}{
+	{"references", mediaTypeReferencesLayerTar},
+	{"scripts", mediaTypeScriptsLayerTar},
+	{"assets", mediaTypeAssetsLayerTar},
+}
+
+// layerGroup is the set of entries packaged into one layer.
+type layerGroup struct {
+	mediaType string // uncompressed
+	entries   []entry
+}
+
+// groupLayers splits entries into the layers of an artifact. Unless layered, everything goes
+// into a single layer. Empty groups are omitted; entries keep their sorted order.
+func groupLayers(entries []entry, layered bool) []layerGroup {
+	if !layered {
+		return []layerGroup{{mediaType: mediaTypeSkillLayerTar, entries: entries}}
+	}
+
+	groups := []layerGroup{{mediaType: mediaTypeSkillFileLayerTar}}
+	index := make(map[string]int)
+	for _, d := range layerDirs {
+		index[d.dir] = len(groups)
+		groups = append(groups, layerGroup{mediaType: d.mediaType})
+	}
+
+	for _, e := range entries {
+		top, _, _ := strings.Cut(filepath.ToSlash(e.name), "/")
+		i := index[top] // zero, the skill file layer, for everything else
+		groups[i].entries = append(groups[i].entries, e)
+	}
+
+	var result []layerGroup
+	for _, g := range groups {
+		if len(g.entries) > 0 {
+			result = append(result, g)
+		}
+	}
+	return result
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package store
+
+import "io/fs"
+
+// fileKey identifies a file on disk, so that hardlinks can be detected.
+type fileKey struct{}
+
+// hardlinkKey is not supported on this platform; hardlinked files are stored as copies.
+func hardlinkKey(fs.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
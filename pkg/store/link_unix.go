@@ -0,0 +1,23 @@
+//go:build unix
+
+package store
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileKey identifies a file on disk, so that hardlinks can be detected.
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// hardlinkKey returns the device and inode of fi, if available.
+func hardlinkKey(fi fs.FileInfo) (fileKey, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
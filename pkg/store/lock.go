@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+const (
+	// LockFileName is the name of the lock file coordinating access to the store between processes.
+	LockFileName = "skr.lock"
+
+	// DefaultLockTimeout is how long an operation waits for another process to release the store.
+	DefaultLockTimeout = 30 * time.Second
+
+	// LockTimeoutEnv overrides DefaultLockTimeout (e.g. "2m").
+	LockTimeoutEnv = "SKR_LOCK_TIMEOUT"
+
+	lockRetryDelay = 50 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when the store stays locked by another process for longer than the lock timeout.
+var ErrLockTimeout = errors.New("timed out waiting for store lock")
+
+type lockMode int
+
+const (
+	unlocked lockMode = iota
+	sharedLock
+	exclusiveLock
+)
+
+// storeLock combines a file lock, which coordinates processes, with an in-process
+// reentrant lock. Backends private to the process have no file lock. Once a mode is held,
+// any request for the same or a weaker mode is granted immediately, so that a caller
+// holding the exclusive lock can call back into the store.
+//
+// The in-process lock belongs to the process, not to a goroutine, so it cannot be upgraded:
+// a request for the exclusive lock waits until every shared hold is released, including the
+// caller's own. Such a request fails with ErrLockTimeout instead of waiting forever.
+type storeLock struct {
+	file    *flock.Flock
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cond  *sync.Cond
+	mode  lockMode
+	count int
+
+	// onAcquire is called whenever the file lock is freshly taken.
+	onAcquire func() error
+}
+
+func newStoreLock(path string, timeout time.Duration, onAcquire func() error) *storeLock {
+	l := &storeLock{
+		timeout:   timeout,
+		onAcquire: onAcquire,
+	}
+	if path != "" {
+		l.file = flock.New(path)
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire takes the lock in the given mode and returns a function that releases it.
+func (l *storeLock) acquire(ctx context.Context, mode lockMode) (func(), error) {
+	return l.acquireWith(ctx, mode, true)
+}
+
+// acquireRaw takes the lock without calling onAcquire, for callers that read the
+// store files directly.
+func (l *storeLock) acquireRaw(ctx context.Context, mode lockMode) (func(), error) {
+	return l.acquireWith(ctx, mode, false)
+}
+
+func (l *storeLock) acquireWith(ctx context.Context, mode lockMode, notify bool) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	deadline := time.Now().Add(l.timeout)
+	for l.mode != unlocked && l.mode < mode {
+		// Held in a weaker mode by other goroutines, or by the caller; wait for them to
+		// finish, waking up periodically to give up on the deadline.
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("failed to lock store: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w after %s: the store is held in shared mode by this process (a shared lock cannot be upgraded)", ErrLockTimeout, l.timeout)
+		}
+		wake := time.AfterFunc(lockRetryDelay, l.cond.Broadcast)
+		l.cond.Wait()
+		wake.Stop()
+	}
+
+	if l.mode >= mode {
+		l.count++
+		return l.release, nil
+	}
+
+	if err := l.lockFile(ctx, mode); err != nil {
+		return nil, err
+	}
+
+	if notify && l.onAcquire != nil {
+		if err := l.onAcquire(); err != nil {
+			l.unlockFile()
+			return nil, err
+		}
+	}
+
+	l.mode = mode
+	l.count = 1
+	return l.release, nil
+}
+
+func (l *storeLock) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.count--
+	if l.count == 0 {
+		l.unlockFile()
+		l.mode = unlocked
+		l.cond.Broadcast()
+	}
+}
+
+// lockFile takes the file lock in the given mode, waiting up to the timeout.
+func (l *storeLock) lockFile(ctx context.Context, mode lockMode) error {
+	if l.file == nil {
+		return nil
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	var ok bool
+	var err error
+	if mode == exclusiveLock {
+		ok, err = l.file.TryLockContext(lockCtx, lockRetryDelay)
+	} else {
+		ok, err = l.file.TryRLockContext(lockCtx, lockRetryDelay)
+	}
+	if !ok || err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return fmt.Errorf("%w after %s: another skr process is using %s", ErrLockTimeout, l.timeout, l.file.Path())
+		}
+		return fmt.Errorf("failed to lock store: %w", err)
+	}
+	return nil
+}
+
+func (l *storeLock) unlockFile() {
+	if l.file != nil {
+		l.file.Unlock()
+	}
+}
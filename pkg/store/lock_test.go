@@ -0,0 +1,139 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock_Contention(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// Two stores on the same path behave like two processes: each holds its own file lock.
+	holder, err := New(dir)
+	require.NoError(t, err)
+	waiter, err := New(dir, WithLockTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+
+	unlock, err := holder.Lock(ctx)
+	require.NoError(t, err)
+
+	_, err = waiter.List(ctx)
+	assert.ErrorIs(t, err, ErrLockTimeout)
+
+	// The holder can still use the store while it holds the lock.
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+	_, err = holder.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	unlock()
+
+	// Once released, the other store sees the tag written by the holder.
+	_, err = waiter.Resolve(ctx, "test:v1")
+	assert.NoError(t, err)
+}
+
+func TestLock_SharedReaders(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	a, err := New(dir)
+	require.NoError(t, err)
+	b, err := New(dir, WithLockTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+
+	unlock, err := a.lock.acquire(ctx, sharedLock)
+	require.NoError(t, err)
+	defer unlock()
+
+	_, err = b.List(ctx)
+	assert.NoError(t, err, "readers should not block each other")
+
+	_, err = b.Prune(ctx)
+	assert.ErrorIs(t, err, ErrLockTimeout, "prune must wait for readers")
+}
+
+func TestLock_NoUpgrade(t *testing.T) {
+	ctx := context.Background()
+	st, err := New(t.TempDir(), WithLockTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+
+	unlock, err := st.RLock(ctx)
+	require.NoError(t, err)
+
+	// Waiting for the exclusive lock while holding the shared one would never return.
+	_, err = st.Lock(ctx)
+	assert.ErrorIs(t, err, ErrLockTimeout)
+
+	unlock()
+	unlockExclusive, err := st.Lock(ctx)
+	require.NoError(t, err)
+	unlockExclusive()
+}
+
+func TestLock_ConcurrentManifestPushes(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// Two stores on the same path behave like two processes, each with its own view of the
+	// index. Pushing a manifest rewrites the index, so neither may lose the other's entries.
+	stores := make([]*Store, 2)
+	for i := range stores {
+		st, err := New(dir)
+		require.NoError(t, err)
+		stores[i] = st
+	}
+
+	config := []byte("{}")
+	configDesc := ocispec.Descriptor{MediaType: MediaTypeSkillConfig, Digest: digest.FromBytes(config), Size: int64(len(config))}
+	require.NoError(t, stores[0].Push(ctx, configDesc, bytes.NewReader(config)))
+
+	var wg sync.WaitGroup
+	digests := make([]digest.Digest, 100)
+	errs := make([]error, len(digests))
+	for i := range digests {
+		manifest, err := json.Marshal(ocispec.Manifest{
+			Versioned:   specs.Versioned{SchemaVersion: 2},
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Config:      configDesc,
+			Layers:      []ocispec.Descriptor{},
+			Annotations: map[string]string{"n": fmt.Sprint(i)},
+		})
+		require.NoError(t, err)
+		desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromBytes(manifest), Size: int64(len(manifest))}
+		digests[i] = desc.Digest
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = stores[i%2].Push(ctx, desc, bytes.NewReader(manifest))
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	fresh, err := New(dir)
+	require.NoError(t, err)
+	index, err := fresh.backend.Index(ctx)
+	require.NoError(t, err)
+	var indexed []digest.Digest
+	for _, desc := range index.Manifests {
+		indexed = append(indexed, desc.Digest)
+	}
+	assert.ElementsMatch(t, digests, indexed)
+}
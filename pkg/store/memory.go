@@ -0,0 +1,315 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// MemoryBackend keeps skills in memory, for tests and ephemeral jobs. Its content is
+// private to the process and lost when it exits.
+type MemoryBackend struct {
+	mu    sync.RWMutex
+	blobs map[digest.Digest]memoryBlob
+	// refs maps tags, and the digests of pushed manifests, to their descriptor.
+	refs map[string]ocispec.Descriptor
+}
+
+type memoryBlob struct {
+	desc     ocispec.Descriptor
+	data     []byte
+	modified time.Time
+}
+
+// interface guard
+var _ Backend = &MemoryBackend{}
+
+// NewMemoryBackend returns an empty in-memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		blobs: make(map[digest.Digest]memoryBlob),
+		refs:  make(map[string]ocispec.Descriptor),
+	}
+}
+
+func (b *MemoryBackend) Init() error      { return nil }
+func (b *MemoryBackend) Reload() error    { return nil }
+func (b *MemoryBackend) LockFile() string { return "" }
+
+func (b *MemoryBackend) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.fetch(target)
+}
+
+func (b *MemoryBackend) fetch(target ocispec.Descriptor) (io.ReadCloser, error) {
+	blob, ok := b.blobs[target.Digest]
+	if !ok {
+		return nil, fmt.Errorf("%s: %s: %w", target.Digest, target.MediaType, errdef.ErrNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(blob.data)), nil
+}
+
+func (b *MemoryBackend) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.blobs[target.Digest]
+	return ok, nil
+}
+
+// Push verifies the content against expected and stores it. Manifests are recorded in
+// the index untagged.
+func (b *MemoryBackend) Push(ctx context.Context, expected ocispec.Descriptor, r io.Reader) error {
+	data, err := content.ReadAll(r, expected)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.blobs[expected.Digest]; ok {
+		return fmt.Errorf("%s: %s: %w", expected.Digest, expected.MediaType, errdef.ErrAlreadyExists)
+	}
+	b.blobs[expected.Digest] = memoryBlob{desc: plainDescriptor(expected), data: data, modified: time.Now()}
+	if isManifest(expected) {
+		b.refs[expected.Digest.String()] = expected
+	}
+	return nil
+}
+
+func (b *MemoryBackend) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	if reference == "" {
+		return errdef.ErrMissingReference
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.blobs[desc.Digest]; !ok {
+		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrNotFound)
+	}
+	b.refs[desc.Digest.String()] = desc
+	b.refs[reference] = desc
+	return nil
+}
+
+// Resolve resolves a tag or the digest of a manifest, falling back to any blob for a digest.
+func (b *MemoryBackend) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if reference == "" {
+		return ocispec.Descriptor{}, errdef.ErrMissingReference
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if desc, ok := b.refs[reference]; ok {
+		if reference == desc.Digest.String() {
+			return plainDescriptor(desc), nil
+		}
+		return desc, nil
+	}
+	if d, err := digest.Parse(reference); err == nil {
+		if blob, ok := b.blobs[d]; ok {
+			return blob.desc, nil
+		}
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+}
+
+func (b *MemoryBackend) Untag(ctx context.Context, reference string) error {
+	if reference == "" {
+		return errdef.ErrMissingReference
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	desc, ok := b.refs[reference]
+	if !ok {
+		return fmt.Errorf("resolving reference %q: %w", reference, errdef.ErrNotFound)
+	}
+	if reference == desc.Digest.String() {
+		return fmt.Errorf("reference %q is a digest and not a tag: %w", reference, errdef.ErrInvalidReference)
+	}
+	delete(b.refs, reference)
+	return nil
+}
+
+// Tags lists the tags after last in sorted order; manifest digests are not tags.
+func (b *MemoryBackend) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	b.mu.RLock()
+	var tags []string
+	for ref, desc := range b.refs {
+		if ref == desc.Digest.String() || (last != "" && ref <= last) {
+			continue
+		}
+		tags = append(tags, ref)
+	}
+	b.mu.RUnlock()
+
+	sort.Strings(tags)
+	return fn(tags)
+}
+
+// Delete removes target and every reference to it, followed by the untagged content it
+// referenced that no other manifest references.
+func (b *MemoryBackend) Delete(ctx context.Context, target ocispec.Descriptor) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.blobs[target.Digest]; !ok {
+		return fmt.Errorf("%s: %s: %w", target.Digest, target.MediaType, errdef.ErrNotFound)
+	}
+
+	queue := []ocispec.Descriptor{target}
+	for len(queue) > 0 {
+		head := queue[0]
+		queue = queue[1:]
+		if _, ok := b.blobs[head.Digest]; !ok {
+			continue
+		}
+
+		successors, err := content.Successors(ctx, memoryFetcher{b}, head)
+		if err != nil {
+			return err
+		}
+		for ref, desc := range b.refs {
+			if desc.Digest == head.Digest {
+				delete(b.refs, ref)
+			}
+		}
+		delete(b.blobs, head.Digest)
+
+		for _, successor := range successors {
+			if !b.isTagged(successor.Digest) && !b.isReferenced(ctx, successor.Digest) {
+				queue = append(queue, successor)
+			}
+		}
+	}
+	return nil
+}
+
+// isTagged reports whether a tag points at d.
+func (b *MemoryBackend) isTagged(d digest.Digest) bool {
+	for ref, desc := range b.refs {
+		if desc.Digest == d && ref != d.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// isReferenced reports whether a manifest in the index references d.
+func (b *MemoryBackend) isReferenced(ctx context.Context, d digest.Digest) bool {
+	for ref, desc := range b.refs {
+		if ref != desc.Digest.String() {
+			continue
+		}
+		successors, err := content.Successors(ctx, memoryFetcher{b}, desc)
+		if err != nil {
+			continue
+		}
+		for _, successor := range successors {
+			if successor.Digest == d {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *MemoryBackend) Blobs(ctx context.Context) ([]BlobInfo, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	blobs := make([]BlobInfo, 0, len(b.blobs))
+	for d, blob := range b.blobs {
+		blobs = append(blobs, BlobInfo{Digest: d, Size: int64(len(blob.data)), Modified: blob.modified})
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].Digest < blobs[j].Digest })
+	return blobs, nil
+}
+
+func (b *MemoryBackend) DeleteBlob(ctx context.Context, d digest.Digest) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.blobs, d)
+	return nil
+}
+
+// Index lists tagged manifests once per tag, followed by the untagged ones.
+func (b *MemoryBackend) Index(ctx context.Context) (ocispec.Index, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	refs := make([]string, 0, len(b.refs))
+	for ref := range b.refs {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	index := ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: []ocispec.Descriptor{}}
+	index.SchemaVersion = 2
+	tagged := make(map[digest.Digest]bool)
+	for _, ref := range refs {
+		desc := b.refs[ref]
+		if ref == desc.Digest.String() {
+			continue
+		}
+		annotations := map[string]string{ocispec.AnnotationRefName: ref}
+		for k, v := range desc.Annotations {
+			if k != ocispec.AnnotationRefName {
+				annotations[k] = v
+			}
+		}
+		desc.Annotations = annotations
+		index.Manifests = append(index.Manifests, desc)
+		tagged[desc.Digest] = true
+	}
+	for _, ref := range refs {
+		desc := b.refs[ref]
+		if ref == desc.Digest.String() && !tagged[desc.Digest] {
+			index.Manifests = append(index.Manifests, desc)
+		}
+	}
+	return index, nil
+}
+
+func (b *MemoryBackend) WriteIndex(ctx context.Context, index ocispec.Index) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refs = make(map[string]ocispec.Descriptor)
+	for _, desc := range index.Manifests {
+		ref := desc.Annotations[ocispec.AnnotationRefName]
+		b.refs[desc.Digest.String()] = plainDescriptor(desc)
+		if ref != "" {
+			b.refs[ref] = desc
+		}
+	}
+	return nil
+}
+
+// memoryFetcher fetches from a MemoryBackend whose lock is already held.
+type memoryFetcher struct {
+	b *MemoryBackend
+}
+
+func (f memoryFetcher) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	return f.b.fetch(target)
+}
+
+// plainDescriptor returns desc with only its media type, digest and size.
+func plainDescriptor(desc ocispec.Descriptor) ocispec.Descriptor {
+	return ocispec.Descriptor{MediaType: desc.MediaType, Digest: desc.Digest, Size: desc.Size}
+}
@@ -0,0 +1,388 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// BlobInfo describes a blob in the store.
+type BlobInfo struct {
+	Digest digest.Digest
+	Size   int64
+	// Modified is when the blob was written to the store.
+	Modified time.Time
+}
+
+// PruneReport lists what Prune removed, or would remove in a dry run.
+type PruneReport struct {
+	Tags      []string
+	Blobs     []BlobInfo
+	Reclaimed int64
+}
+
+type pruneOptions struct {
+	keepLast   int
+	olderThan  time.Duration
+	referenced []string
+	dryRun     bool
+	now        time.Time
+}
+
+// PruneOption configures a call to Prune.
+type PruneOption func(*pruneOptions)
+
+// WithKeepLast removes all but the n most recent tags of each repository.
+func WithKeepLast(n int) PruneOption {
+	return func(o *pruneOptions) { o.keepLast = n }
+}
+
+// WithOlderThan removes tags created longer than d ago.
+func WithOlderThan(d time.Duration) PruneOption {
+	return func(o *pruneOptions) { o.olderThan = d }
+}
+
+// WithReferenced removes tags that do not appear in refs. A reference without a tag
+// matches both the untagged name and ":latest", and a reference with a digest
+// ("repo@sha256:...") keeps every tag of that manifest. Other references, such as version
+// constraints, are ignored; see resolution.Resolver.Referenced to expand them along with
+// dependencies.
+func WithReferenced(refs []string) PruneOption {
+	return func(o *pruneOptions) {
+		o.referenced = refs
+		if o.referenced == nil {
+			o.referenced = []string{}
+		}
+	}
+}
+
+// WithDryRun reports what would be removed without changing the store.
+func WithDryRun() PruneOption {
+	return func(o *pruneOptions) { o.dryRun = true }
+}
+
+// taggedManifest is a tag in the store together with what it points at.
+type taggedManifest struct {
+	ref      string
+	repo     string
+	tag      string
+	desc     ocispec.Descriptor
+	manifest ocispec.Manifest
+	created  time.Time
+}
+
+// blobs returns the digests of the manifest and everything it references.
+func (t taggedManifest) blobs() []digest.Digest {
+	digests := []digest.Digest{t.desc.Digest, t.manifest.Config.Digest}
+	for _, layer := range t.manifest.Layers {
+		digests = append(digests, layer.Digest)
+	}
+	return digests
+}
+
+// size returns the total size of the manifest and everything it references.
+func (t taggedManifest) size() int64 {
+	size := t.desc.Size + t.manifest.Config.Size
+	for _, layer := range t.manifest.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+// Prune applies the retention options, removing the tags they select, and then deletes
+// every blob that no remaining tag references, along with the cached extractions of
+// deleted layers (see ExtractDir).
+//
+// Retention rules are independent: a tag is removed if any of them selects it. Without
+// options, Prune only garbage collects unreferenced blobs.
+func (s *Store) Prune(ctx context.Context, opts ...PruneOption) (*PruneReport, error) {
+	options := pruneOptions{now: time.Now()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	unlock, err := s.lock.acquire(ctx, exclusiveLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// 1. Load every tag
+	tagged, err := s.taggedManifests(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to traverse tags: %w", err)
+	}
+
+	// 2. Apply the retention rules
+	remove := selectForRemoval(tagged, options)
+
+	// 3. Identify all blobs reachable from the remaining tags
+	reachable := make(map[digest.Digest]bool)
+	for _, t := range tagged {
+		if remove[t.ref] {
+			continue
+		}
+		for _, d := range t.blobs() {
+			reachable[d] = true
+		}
+	}
+
+	report := &PruneReport{}
+	for _, t := range tagged {
+		if remove[t.ref] {
+			report.Tags = append(report.Tags, t.ref)
+		}
+	}
+
+	blobs, err := s.backend.Blobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		if reachable[blob.Digest] {
+			continue
+		}
+		report.Blobs = append(report.Blobs, blob)
+		report.Reclaimed += blob.Size
+	}
+
+	if options.dryRun {
+		return report, nil
+	}
+
+	// 4. Remove the selected tags. A manifest that no remaining tag points to is deleted
+	// outright, so that it also leaves the index.
+	for _, t := range tagged {
+		if !remove[t.ref] {
+			continue
+		}
+		if reachable[t.desc.Digest] {
+			err = s.backend.Untag(ctx, t.ref)
+		} else {
+			err = s.deleteManifest(ctx, t.desc)
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to remove tag %s: %w", t.ref, err)
+		}
+	}
+
+	// 5. Drop untagged manifests from the index, then delete every unreachable blob
+	untagged, err := s.untaggedManifests(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, desc := range untagged {
+		if !reachable[desc.Digest] {
+			if err := s.deleteManifest(ctx, desc); err != nil {
+				return report, fmt.Errorf("failed to remove manifest %s: %w", desc.Digest, err)
+			}
+		}
+	}
+
+	for _, blob := range report.Blobs {
+		if err := s.backend.DeleteBlob(ctx, blob.Digest); err != nil {
+			return report, err
+		}
+	}
+
+	if err := s.pruneExtracted(ctx); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// deleteManifest removes a manifest from the index and the blob store.
+func (s *Store) deleteManifest(ctx context.Context, desc ocispec.Descriptor) error {
+	err := s.backend.Delete(ctx, desc)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// taggedManifests resolves every tag in the store and parses its manifest. If strict is
+// false, tags whose manifest cannot be resolved or read are skipped rather than failing the
+// whole listing; 'skr system fsck' reports them.
+func (s *Store) taggedManifests(ctx context.Context, strict bool) ([]taggedManifest, error) {
+	var tagged []taggedManifest
+	// When each blob was written, only loaded for artifacts without a creation time.
+	var modified map[digest.Digest]time.Time
+
+	err := s.backend.Tags(ctx, "", func(tags []string) error {
+		for _, tag := range tags {
+			// Resolve tag to manifest descriptor
+			desc, err := s.backend.Resolve(ctx, tag)
+			if err != nil {
+				if !strict {
+					continue
+				}
+				return err
+			}
+
+			// Fetch and parse manifest to find children (config + layers)
+			var manifest ocispec.Manifest
+			if err := s.readJSON(ctx, desc, &manifest); err != nil {
+				if !strict {
+					continue
+				}
+				return fmt.Errorf("failed to read manifest for %s: %w", tag, err)
+			}
+
+			created, ok := s.createdTime(ctx, manifest)
+			if !ok {
+				if modified == nil {
+					if modified, err = s.blobModTimes(ctx); err != nil {
+						return err
+					}
+				}
+				created = modified[desc.Digest]
+			}
+
+			// Names that do not parse, such as those written by other tools, form their own
+			// repository.
+			parsed, err := ParseReference(tag)
+			if err != nil {
+				parsed = Reference{Repository: tag}
+			}
+			tagged = append(tagged, taggedManifest{
+				ref:      tag,
+				repo:     parsed.Repository,
+				tag:      parsed.Tag,
+				desc:     desc,
+				manifest: manifest,
+				created:  created,
+			})
+		}
+		return nil
+	})
+	return tagged, err
+}
+
+// untaggedManifests returns the manifests listed in the index without a tag.
+func (s *Store) untaggedManifests(ctx context.Context) ([]ocispec.Descriptor, error) {
+	index, err := s.backend.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var untagged []ocispec.Descriptor
+	for _, desc := range index.Manifests {
+		if desc.Annotations[ocispec.AnnotationRefName] == "" {
+			untagged = append(untagged, desc)
+		}
+	}
+	return untagged, nil
+}
+
+// createdTime returns the "created" field of an artifact's config, if set. Artifacts
+// without one are dated by when their manifest was added to the store instead.
+func (s *Store) createdTime(ctx context.Context, manifest ocispec.Manifest) (time.Time, bool) {
+	var config struct {
+		Created time.Time `json:"created"`
+	}
+	if err := s.readJSON(ctx, manifest.Config, &config); err == nil && config.Created.After(epoch) {
+		return config.Created, true
+	}
+	return time.Time{}, false
+}
+
+// blobModTimes returns when each blob was written to the store.
+func (s *Store) blobModTimes(ctx context.Context) (map[digest.Digest]time.Time, error) {
+	blobs, err := s.backend.Blobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	modified := make(map[digest.Digest]time.Time, len(blobs))
+	for _, blob := range blobs {
+		modified[blob.Digest] = blob.Modified
+	}
+	return modified, nil
+}
+
+// readJSON fetches a blob and decodes it into v.
+func (s *Store) readJSON(ctx context.Context, desc ocispec.Descriptor, v any) error {
+	rc, err := s.backend.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// selectForRemoval returns the set of tags selected by the retention options.
+func selectForRemoval(tagged []taggedManifest, options pruneOptions) map[string]bool {
+	remove := make(map[string]bool)
+
+	if options.keepLast > 0 {
+		byRepo := make(map[string][]taggedManifest)
+		for _, t := range tagged {
+			byRepo[t.repo] = append(byRepo[t.repo], t)
+		}
+		for _, tags := range byRepo {
+			// Most recent first; ties are broken by name so the result is stable.
+			sort.Slice(tags, func(i, j int) bool {
+				if !tags[i].created.Equal(tags[j].created) {
+					return tags[i].created.After(tags[j].created)
+				}
+				return tags[i].ref < tags[j].ref
+			})
+			for _, t := range tags[min(options.keepLast, len(tags)):] {
+				remove[t.ref] = true
+			}
+		}
+	}
+
+	if options.olderThan > 0 {
+		cutoff := options.now.Add(-options.olderThan)
+		for _, t := range tagged {
+			if t.created.Before(cutoff) {
+				remove[t.ref] = true
+			}
+		}
+	}
+
+	if options.referenced != nil {
+		refs := make(map[string]bool)
+		digests := make(map[digest.Digest]bool)
+		for _, ref := range options.referenced {
+			// Anything else, such as a version constraint, must be expanded by the caller.
+			parsed, err := ParseReference(ref)
+			if err != nil {
+				continue
+			}
+			if parsed.Digest != "" {
+				digests[parsed.Digest] = true
+				if parsed.Tag == "" {
+					continue
+				}
+			}
+			// Pull stores a reference without a tag as written.
+			if parsed.Tag == "" {
+				refs[parsed.Repository] = true
+				parsed.Tag = "latest"
+			}
+			refs[Reference{Repository: parsed.Repository, Tag: parsed.Tag}.String()] = true
+		}
+		for _, t := range tagged {
+			if !refs[t.ref] && !digests[t.desc.Digest] {
+				remove[t.ref] = true
+			}
+		}
+	}
+
+	return remove
+}
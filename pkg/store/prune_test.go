@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildAt builds a distinct skill tagged as ref, created at the given time.
+func buildAt(t *testing.T, st *Store, ref string, created time.Time) {
+	t.Helper()
+	t.Setenv(SourceDateEpochEnv, strconv.FormatInt(created.Unix(), 10))
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "version.txt"), []byte(ref), 0644))
+
+	_, err := st.Build(context.Background(), srcDir, ref, nil)
+	require.NoError(t, err)
+}
+
+func listTags(t *testing.T, st *Store) []string {
+	t.Helper()
+	tags, err := st.List(context.Background())
+	require.NoError(t, err)
+	return tags
+}
+
+func TestPrune_Retention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	setup := func(t *testing.T) *Store {
+		st, err := New(t.TempDir())
+		require.NoError(t, err)
+		buildAt(t, st, "example.com/a:v1", now.Add(-72*time.Hour))
+		buildAt(t, st, "example.com/a:v2", now.Add(-48*time.Hour))
+		buildAt(t, st, "example.com/a:v3", now.Add(-1*time.Hour))
+		buildAt(t, st, "localhost:5000/b:v1", now.Add(-96*time.Hour))
+		return st
+	}
+
+	t.Run("keep last", func(t *testing.T) {
+		st := setup(t)
+		report, err := st.Prune(ctx, WithKeepLast(1))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"example.com/a:v1", "example.com/a:v2"}, report.Tags)
+		assert.ElementsMatch(t, []string{"example.com/a:v3", "localhost:5000/b:v1"}, listTags(t, st))
+		assert.NotEmpty(t, report.Blobs)
+		assert.Positive(t, report.Reclaimed)
+	})
+
+	t.Run("older than", func(t *testing.T) {
+		st := setup(t)
+		report, err := st.Prune(ctx, WithOlderThan(50*time.Hour))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"example.com/a:v1", "localhost:5000/b:v1"}, report.Tags)
+	})
+
+	t.Run("referenced", func(t *testing.T) {
+		st := setup(t)
+		report, err := st.Prune(ctx, WithReferenced([]string{"example.com/a:v2", "localhost:5000/b:v1"}))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"example.com/a:v1", "example.com/a:v3"}, report.Tags)
+	})
+
+	t.Run("referenced without a tag", func(t *testing.T) {
+		st := setup(t)
+		buildAt(t, st, "localhost:5000/c", now.Add(-1*time.Hour))
+		buildAt(t, st, "localhost:5000/d:latest", now.Add(-1*time.Hour))
+		report, err := st.Prune(ctx, WithDryRun(), WithReferenced([]string{"localhost:5000/c", "localhost:5000/d"}))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"example.com/a:v1", "example.com/a:v2", "example.com/a:v3", "localhost:5000/b:v1"}, report.Tags)
+	})
+
+	t.Run("referenced ignores constraints", func(t *testing.T) {
+		st := setup(t)
+		report, err := st.Prune(ctx, WithDryRun(), WithReferenced([]string{"example.com/a@^1", "localhost:5000/b:v1"}))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"example.com/a:v1", "example.com/a:v2", "example.com/a:v3"}, report.Tags)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		st := setup(t)
+		report, err := st.Prune(ctx, WithKeepLast(1), WithDryRun())
+		require.NoError(t, err)
+		assert.Len(t, report.Tags, 2)
+		assert.Len(t, listTags(t, st), 4, "dry run must not remove tags")
+
+		verify, err := st.Verify(ctx, false)
+		require.NoError(t, err)
+		assert.True(t, verify.Healthy())
+	})
+
+	t.Run("leaves a consistent store", func(t *testing.T) {
+		st := setup(t)
+		_, err := st.Prune(ctx, WithKeepLast(1))
+		require.NoError(t, err)
+
+		verify, err := st.Verify(ctx, false)
+		require.NoError(t, err)
+		assert.True(t, verify.Healthy(), "%v", verify.Problems)
+		assert.Empty(t, verify.Orphans)
+	})
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var (
+	// ErrAmbiguousDigest is returned when a short digest matches more than one artifact.
+	ErrAmbiguousDigest = errors.New("ambiguous short digest")
+
+	domainRegex    = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)(?:\.(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?))*(?::[0-9]+)?$`)
+	componentRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagRegex       = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+	hexRegex       = regexp.MustCompile(`^[a-f0-9]+$`)
+)
+
+// Reference is a parsed artifact reference of the form repository[:tag][@digest].
+// The repository may start with a registry host, e.g. ghcr.io/org/skill:v1.
+type Reference struct {
+	Repository string
+	Tag        string
+	Digest     digest.Digest
+}
+
+// ParseReference parses and validates a reference using the OCI distribution grammar.
+func ParseReference(ref string) (Reference, error) {
+	var r Reference
+	name := ref
+
+	if idx := strings.Index(name, "@"); idx != -1 {
+		d, err := digest.Parse(name[idx+1:])
+		if err != nil {
+			return Reference{}, fmt.Errorf("invalid reference %q: %w", ref, err)
+		}
+		r.Digest = d
+		name = name[:idx]
+	}
+
+	// A colon after the last slash separates the tag; one before it is a registry port.
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		r.Tag = name[idx+1:]
+		name = name[:idx]
+		if !tagRegex.MatchString(r.Tag) {
+			return Reference{}, fmt.Errorf("invalid reference %q: invalid tag %q", ref, r.Tag)
+		}
+	}
+
+	if name == "" {
+		return Reference{}, fmt.Errorf("invalid reference %q: missing repository", ref)
+	}
+	components := strings.Split(name, "/")
+	if len(components) > 1 && isDomain(components[0]) {
+		if !domainRegex.MatchString(components[0]) {
+			return Reference{}, fmt.Errorf("invalid reference %q: invalid registry %q", ref, components[0])
+		}
+		components = components[1:]
+	}
+	for _, component := range components {
+		if !componentRegex.MatchString(component) {
+			return Reference{}, fmt.Errorf("invalid reference %q: invalid repository component %q", ref, component)
+		}
+	}
+	r.Repository = name
+
+	return r, nil
+}
+
+// isDomain reports whether the first path component names a registry host rather than
+// a repository, following the same heuristic as docker.
+func isDomain(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost" || strings.ToLower(component) != component
+}
+
+// String formats the reference as repository[:tag][@digest].
+func (r Reference) String() string {
+	s := r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest.String()
+	}
+	return s
+}
+
+// MinDigestPrefix is the shortest digest prefix Lookup accepts, the length of the IMAGE ID
+// shown by 'skr system list'.
+const MinDigestPrefix = 12
+
+// Lookup resolves a tag, a full digest, or a unique prefix of a digest (such as the
+// IMAGE ID shown by 'skr system list') to the descriptor of a tagged artifact. A prefix
+// must have at least MinDigestPrefix characters.
+func (s *Store) Lookup(ctx context.Context, ref string) (ocispec.Descriptor, error) {
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer unlock()
+
+	desc, resolveErr := s.backend.Resolve(ctx, ref)
+	if resolveErr == nil {
+		return desc, nil
+	}
+
+	prefix := strings.TrimPrefix(ref, digest.SHA256.String()+":")
+	if !hexRegex.MatchString(prefix) {
+		return ocispec.Descriptor{}, resolveErr
+	}
+	if len(prefix) < MinDigestPrefix {
+		return ocispec.Descriptor{}, fmt.Errorf("%w (a short digest needs at least %d characters)", resolveErr, MinDigestPrefix)
+	}
+
+	var tags []string
+	if err := s.backend.Tags(ctx, "", func(list []string) error {
+		tags = append(tags, list...)
+		return nil
+	}); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	var match ocispec.Descriptor
+	for _, tag := range tags {
+		candidate, err := s.backend.Resolve(ctx, tag)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		if candidate.Digest.Algorithm() != digest.SHA256 || !strings.HasPrefix(candidate.Digest.Encoded(), prefix) {
+			continue
+		}
+		if match.Digest != "" && match.Digest != candidate.Digest {
+			return ocispec.Descriptor{}, fmt.Errorf("%w: %s matches %s and %s", ErrAmbiguousDigest, ref, match.Digest, candidate.Digest)
+		}
+		match = ocispec.Descriptor{MediaType: candidate.MediaType, Digest: candidate.Digest, Size: candidate.Size}
+	}
+	if match.Digest == "" {
+		return ocispec.Descriptor{}, resolveErr
+	}
+	return match, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    Reference
+		wantErr bool
+	}{
+		{ref: "my-skill", want: Reference{Repository: "my-skill"}},
+		{ref: "my-skill:v1", want: Reference{Repository: "my-skill", Tag: "v1"}},
+		{ref: "ghcr.io/org/my-skill:v1.2.3", want: Reference{Repository: "ghcr.io/org/my-skill", Tag: "v1.2.3"}},
+		{ref: "localhost:5000/my-skill", want: Reference{Repository: "localhost:5000/my-skill"}},
+		{ref: "localhost:5000/my-skill:v1", want: Reference{Repository: "localhost:5000/my-skill", Tag: "v1"}},
+		{
+			ref:  "ghcr.io/org/my-skill@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			want: Reference{Repository: "ghcr.io/org/my-skill", Digest: "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		},
+		{ref: "", wantErr: true},
+		{ref: ":v1", wantErr: true},
+		{ref: "My-Skill:v1", wantErr: true},
+		{ref: "org/my skill:v1", wantErr: true},
+		{ref: "my-skill:-v1", wantErr: true},
+		{ref: "my-skill@sha256:short", wantErr: true},
+		{ref: "ghcr.io//skill", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseReference(tt.ref)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ref, got.String())
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+	desc, err := st.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	for _, ref := range []string{"test:v1", desc.Digest.String(), desc.Digest.Encoded()[:12], "sha256:" + desc.Digest.Encoded()[:16]} {
+		got, err := st.Lookup(ctx, ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, desc.Digest, got.Digest, ref)
+	}
+
+	_, err = st.Lookup(ctx, "test:v2")
+	assert.Error(t, err)
+	_, err = st.Lookup(ctx, desc.Digest.Encoded()[:8])
+	assert.ErrorContains(t, err, "at least 12 characters")
+
+	require.NoError(t, st.Tag(ctx, desc, "ghcr.io/org/test:v1"))
+	got, err := st.Resolve(ctx, "ghcr.io/org/test:v1")
+	require.NoError(t, err)
+	assert.Equal(t, desc.Digest, got.Digest)
+
+	assert.Error(t, st.Tag(ctx, desc, "Invalid Ref"))
+}
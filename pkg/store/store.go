@@ -1,37 +1,47 @@
 package store
 
 import (
-	"archive/tar"
-	"bytes"
-	"compress/gzip"
 	"context"
 	_ "crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/adrg/xdg"
-	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
-	"oras.land/oras-go/v2/content"
-	"oras.land/oras-go/v2/content/oci"
 )
 
 const (
-	MediaTypeSkillLayer  = "application/vnd.agentskills.skill.layer.v1+tar+gzip"
+	MediaTypeSkillLayer  = mediaTypeSkillLayerTar + "+gzip"
 	MediaTypeSkillConfig = "application/vnd.agentskills.skill.config.v1+json"
 	StoreDirName         = "skr/store"
 )
 
+// Store holds built and pulled skills, by default in a local OCI layout (see Backend).
+//
+// Access is coordinated between processes with a file lock: reads take a shared lock
+// and mutations (build, tag, delete, prune) take an exclusive one.
 type Store struct {
-	path string
-	oci  *oci.Store
+	backend Backend
+	lock    *storeLock
 }
 
-func New(path string) (*Store, error) {
+// Option configures a Store.
+type Option func(*storeOptions)
+
+type storeOptions struct {
+	lockTimeout time.Duration
+}
+
+// WithLockTimeout sets how long operations wait for another process to release the store.
+func WithLockTimeout(d time.Duration) Option {
+	return func(o *storeOptions) { o.lockTimeout = d }
+}
+
+// New opens the store in the OCI layout at path, or in the XDG data directory if path
+// is empty.
+func New(path string, opts ...Option) (*Store, error) {
 	if path == "" {
 		dataPath, err := xdg.DataFile(StoreDirName)
 		if err != nil {
@@ -40,154 +50,77 @@ func New(path string) (*Store, error) {
 		path = dataPath
 	}
 
-	err := os.MkdirAll(path, 0755)
+	backend, err := NewFilesystemBackend(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create store directory: %w", err)
-	}
-
-	ociStore, err := oci.New(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize OCI store: %w", err)
+		return nil, err
 	}
-
-	return &Store{
-		path: path,
-		oci:  ociStore,
-	}, nil
+	return NewWithBackend(backend, opts...)
 }
 
-func (s *Store) Build(ctx context.Context, srcDir string, tag string, annotations map[string]string) error {
-	// 1. Create a tarball of the directory
-	buf := &bytes.Buffer{}
-	gw := gzip.NewWriter(buf)
-	tw := tar.NewWriter(gw)
-
-	err := filepath.Walk(srcDir, func(file string, fi os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Calculate relative path
-		relPath, err := filepath.Rel(srcDir, file)
-		if err != nil {
-			return err
-		}
-
-		if relPath == "." {
-			return nil
-		}
+// NewMemory returns an empty store kept in memory, e.g. for tests.
+func NewMemory(opts ...Option) (*Store, error) {
+	return NewWithBackend(NewMemoryBackend(), opts...)
+}
 
-		header, err := tar.FileInfoHeader(fi, fi.Name())
+// NewWithBackend returns a store keeping its content in backend.
+func NewWithBackend(backend Backend, opts ...Option) (*Store, error) {
+	options := storeOptions{lockTimeout: DefaultLockTimeout}
+	if value := os.Getenv(LockTimeoutEnv); value != "" {
+		d, err := time.ParseDuration(value)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("invalid %s %q: %w", LockTimeoutEnv, value, err)
 		}
-
-		header.Name = relPath
-
-		if err := tw.WriteHeader(header); err != nil {
-			return err
-		}
-
-		if !fi.IsDir() {
-			data, err := os.Open(file)
-			if err != nil {
-				return err
-			}
-			defer data.Close()
-			if _, err := io.Copy(tw, data); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to walk source directory: %w", err)
-	}
-
-	if err := tw.Close(); err != nil {
-		return err
-	}
-	if err := gw.Close(); err != nil {
-		return err
-	}
-
-	layerBytes := buf.Bytes()
-	layerDigest := digest.FromBytes(layerBytes)
-	layerSize := int64(len(layerBytes))
-
-	// 2. Push layer to store
-	layerDesc := ocispec.Descriptor{
-		MediaType: MediaTypeSkillLayer,
-		Digest:    layerDigest,
-		Size:      layerSize,
-	}
-
-	err = s.pushBlob(ctx, layerDesc, bytes.NewReader(layerBytes))
-	if err != nil {
-		return fmt.Errorf("failed to push layer: %w", err)
+		options.lockTimeout = d
 	}
-
-	// 3. Create and push config (empty for now)
-	config := map[string]string{
-		"created": time.Now().UTC().Format(time.RFC3339),
-	}
-	configBytes, _ := json.Marshal(config)
-	configDigest := digest.FromBytes(configBytes)
-	configDesc := ocispec.Descriptor{
-		MediaType: MediaTypeSkillConfig,
-		Digest:    configDigest,
-		Size:      int64(len(configBytes)),
-	}
-	err = s.pushBlob(ctx, configDesc, bytes.NewReader(configBytes))
-	if err != nil {
-		return fmt.Errorf("failed to push config: %w", err)
+	for _, opt := range opts {
+		opt(&options)
 	}
 
-	// 4. Create and push Manifest
-	manifest := ocispec.Manifest{
-		Config:      configDesc,
-		Layers:      []ocispec.Descriptor{layerDesc},
-		Annotations: annotations,
-	}
-	manifest.SchemaVersion = 2
+	s := &Store{backend: backend}
+	s.lock = newStoreLock(backend.LockFile(), options.lockTimeout, backend.Reload)
 
-	manifestBytes, err := json.Marshal(manifest)
+	// Initializing writes the layout, so do it under the exclusive lock.
+	unlock, err := s.lock.acquireRaw(context.Background(), exclusiveLock)
 	if err != nil {
-		return fmt.Errorf("failed to marshal manifest: %w", err)
-	}
-
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifestBytes),
-		Size:      int64(len(manifestBytes)),
-	}
-
-	err = s.pushBlob(ctx, manifestDesc, bytes.NewReader(manifestBytes))
-	if err != nil {
-		return fmt.Errorf("failed to push manifest: %w", err)
+		return nil, err
 	}
+	defer unlock()
 
-	// 5. Tag the manifest
-	if tag != "" {
-		err = s.oci.Tag(ctx, manifestDesc, tag)
-		if err != nil {
-			return fmt.Errorf("failed to tag artifact: %w", err)
-		}
+	if err := backend.Init(); err != nil {
+		return nil, err
 	}
+	return s, nil
+}
 
-	return nil
+// Lock takes the exclusive store lock until the returned function is called.
+// Store methods called while it is held do not block, which allows multi-step
+// operations such as tagging a pulled artifact to complete without a concurrent prune in
+// between. It must not be called while holding RLock, which cannot be upgraded.
+func (s *Store) Lock(ctx context.Context) (func(), error) {
+	return s.lock.acquire(ctx, exclusiveLock)
 }
 
 // Fetch retrieves content by digest
 func (s *Store) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
-	return s.oci.Fetch(ctx, target)
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return s.backend.Fetch(ctx, target)
 }
 
 // List returns a list of all tags in the store
 func (s *Store) List(ctx context.Context) ([]string, error) {
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	var tags []string
-	err := s.oci.Tags(ctx, "", func(tagsList []string) error {
+	err = s.backend.Tags(ctx, "", func(tagsList []string) error {
 		tags = append(tags, tagsList...)
 		return nil
 	})
@@ -199,120 +132,78 @@ func (s *Store) List(ctx context.Context) ([]string, error) {
 
 // Resolve resolves a reference (tag/digest) to a descriptor
 func (s *Store) Resolve(ctx context.Context, ref string) (ocispec.Descriptor, error) {
-	return s.oci.Resolve(ctx, ref)
-}
-
-// Prune removes all unreferenced blobs from the store
-func (s *Store) Prune(ctx context.Context) (int, int64, error) {
-	// 1. Identify all reachable blobs
-	reachable := make(map[string]bool)
-
-	err := s.oci.Tags(ctx, "", func(tags []string) error {
-		for _, tag := range tags {
-			// Resolve tag to manifest descriptor
-			desc, err := s.oci.Resolve(ctx, tag)
-			if err != nil {
-				return err
-			}
-			reachable[desc.Digest.String()] = true
-
-			// Fetch and parse manifest to find children (config + layers)
-			rc, err := s.oci.Fetch(ctx, desc)
-			if err != nil {
-				return err
-			}
-			manifestBytes, err := io.ReadAll(rc)
-			rc.Close()
-			if err != nil {
-				return err
-			}
-
-			var manifest ocispec.Manifest
-			if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-				return err
-			}
-
-			// Mark config as reachable
-			reachable[manifest.Config.Digest.String()] = true
-
-			// Mark layers as reachable
-			for _, layer := range manifest.Layers {
-				reachable[layer.Digest.String()] = true
-			}
-		}
-		return nil
-	})
+	unlock, err := s.lock.acquire(ctx, sharedLock)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to traverse tags: %w", err)
+		return ocispec.Descriptor{}, err
 	}
+	defer unlock()
 
-	// 2. Iterate through all blobs in storage and delete unreachable ones
-	blobsDir := filepath.Join(s.path, "blobs", "sha256")
-	entries, err := os.ReadDir(blobsDir)
-	if os.IsNotExist(err) {
-		return 0, 0, nil // Nothing to prune
-	}
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to read blobs directory: %w", err)
-	}
-
-	deletedCount := 0
-	var deletedSize int64
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		digestStr := "sha256:" + entry.Name()
-		if !reachable[digestStr] {
-			info, err := entry.Info()
-			if err == nil {
-				deletedSize += info.Size()
-			}
-
-			path := filepath.Join(blobsDir, entry.Name())
-			if err := os.Remove(path); err != nil {
-				return deletedCount, deletedSize, fmt.Errorf("failed to remove blob %s: %w", path, err)
-			}
-			deletedCount++
-		}
-	}
-
-	return deletedCount, deletedSize, nil
+	return s.backend.Resolve(ctx, ref)
 }
 
-// interface guard
-var _ content.Storage = &oci.Store{}
-
 // Exists checks if a target descriptor exists in the store
 func (s *Store) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
-	return s.oci.Exists(ctx, target)
+	unlock, err := s.lock.acquire(ctx, sharedLock)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	return s.backend.Exists(ctx, target)
 }
 
 // Push pushes content to the store
+// Blob writes are atomic, so concurrent pushes only need to exclude a prune. Manifests are
+// also recorded in the index, which is rewritten as a whole, so they take the exclusive lock
+// and must not be pushed while holding RLock.
 func (s *Store) Push(ctx context.Context, desc ocispec.Descriptor, r io.Reader) error {
-	return s.oci.Push(ctx, desc, r)
+	mode := sharedLock
+	if isManifest(desc) {
+		mode = exclusiveLock
+	}
+	unlock, err := s.lock.acquire(ctx, mode)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.backend.Push(ctx, desc, r)
 }
 
 // Tag aliases a descriptor with a reference
 func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
-	return s.oci.Tag(ctx, desc, reference)
+	if _, err := ParseReference(reference); err != nil {
+		return err
+	}
+
+	unlock, err := s.lock.acquire(ctx, exclusiveLock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.backend.Tag(ctx, desc, reference)
 }
 
 // Delete removes a descriptor from the store
 func (s *Store) Delete(ctx context.Context, target ocispec.Descriptor) error {
-	return s.oci.Delete(ctx, target)
+	unlock, err := s.lock.acquire(ctx, exclusiveLock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.backend.Delete(ctx, target)
 }
 
 // pushBlob pushes content if it doesn't already exist
 func (s *Store) pushBlob(ctx context.Context, desc ocispec.Descriptor, r io.Reader) error {
-	exists, err := s.oci.Exists(ctx, desc)
+	exists, err := s.backend.Exists(ctx, desc)
 	if err != nil {
 		return err
 	}
 	if exists {
 		return nil
 	}
-	return s.oci.Push(ctx, desc, r)
+	return s.backend.Push(ctx, desc, r)
 }
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ProblemKind classifies an integrity problem found by Verify.
+type ProblemKind string
+
+const (
+	// ProblemCorruptBlob means the blob content does not match its digest.
+	ProblemCorruptBlob ProblemKind = "corrupt-blob"
+	// ProblemTruncatedBlob means the blob is shorter than the descriptor referencing it.
+	ProblemTruncatedBlob ProblemKind = "truncated-blob"
+	// ProblemMissingBlob means a referenced blob is not in the store.
+	ProblemMissingBlob ProblemKind = "missing-blob"
+	// ProblemInvalidManifest means a tagged manifest cannot be parsed.
+	ProblemInvalidManifest ProblemKind = "invalid-manifest"
+	// ProblemInvalidIndex means index.json cannot be parsed; no tags can be checked.
+	ProblemInvalidIndex ProblemKind = "invalid-index"
+	// ProblemCorruptExtraction means a file in the extraction cache (see ExtractDir) no
+	// longer matches its layer, e.g. because a hardlinked install was edited in place.
+	ProblemCorruptExtraction ProblemKind = "corrupt-extraction"
+)
+
+// Problem is a single integrity problem found by Verify.
+type Problem struct {
+	Kind   ProblemKind
+	Digest digest.Digest
+	Tag    string // tag whose artifact is affected, if any
+	Detail string
+}
+
+// VerifyReport summarizes the result of Verify.
+type VerifyReport struct {
+	BlobsChecked int
+	TagsChecked  int
+	Problems     []Problem
+	// Orphans are valid blobs that no tag references. They are removed by Prune.
+	Orphans []digest.Digest
+	// RemovedTags lists the tags dropped from the index when repairing.
+	RemovedTags []string
+	// RemovedBlobs lists the corrupt blobs deleted when repairing.
+	RemovedBlobs []digest.Digest
+	// RemovedExtractions lists the layers whose cached extraction was deleted when repairing.
+	RemovedExtractions []digest.Digest
+}
+
+// Healthy reports whether no problems were found.
+func (r *VerifyReport) Healthy() bool {
+	return len(r.Problems) == 0
+}
+
+type blobState struct {
+	size  int64
+	valid bool
+}
+
+// Verify checks the integrity of the store. It re-hashes every blob and checks that every
+// tag resolves to a parseable manifest whose config and layers exist and are intact, and
+// that the extraction cache matches the layers it was extracted from.
+//
+// With repair set, tags referencing damaged artifacts are dropped from the index, corrupt
+// blobs are deleted, so that they can be pulled or built again, and damaged extractions
+// are deleted, so that the next install extracts them again.
+//
+// Verify reads the blobs and the index directly, so it works even when the index cannot
+// be loaded.
+func (s *Store) Verify(ctx context.Context, repair bool) (*VerifyReport, error) {
+	mode := sharedLock
+	if repair {
+		mode = exclusiveLock
+	}
+	unlock, err := s.lock.acquireRaw(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	report := &VerifyReport{}
+
+	// 1. Re-hash every blob
+	blobs, err := s.hashBlobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.BlobsChecked = len(blobs)
+
+	// 2. Check every manifest listed in the index
+	index, err := s.backend.Index(ctx)
+	if errors.Is(err, ErrInvalidIndex) {
+		report.Problems = append(report.Problems, Problem{
+			Kind:   ProblemInvalidIndex,
+			Detail: err.Error(),
+		})
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reachable := make(map[digest.Digest]bool)
+	layers := make(map[digest.Digest]ocispec.Descriptor)
+	var kept []ocispec.Descriptor
+
+	for _, desc := range index.Manifests {
+		tag := desc.Annotations[ocispec.AnnotationRefName]
+		if tag != "" {
+			report.TagsChecked++
+		}
+
+		problems := s.verifyManifest(ctx, desc, blobs, reachable, layers)
+		for i := range problems {
+			problems[i].Tag = tag
+		}
+		report.Problems = append(report.Problems, problems...)
+
+		if len(problems) == 0 {
+			kept = append(kept, desc)
+		} else if repair {
+			if tag != "" {
+				report.RemovedTags = append(report.RemovedTags, tag)
+			}
+		} else {
+			kept = append(kept, desc)
+		}
+	}
+
+	// 3. Classify blobs that nothing references
+	for _, d := range sortedDigests(blobs) {
+		if reachable[d] {
+			continue
+		}
+		if blobs[d].valid {
+			report.Orphans = append(report.Orphans, d)
+		} else {
+			report.Problems = append(report.Problems, Problem{
+				Kind:   ProblemCorruptBlob,
+				Digest: d,
+				Detail: "content does not match digest",
+			})
+		}
+	}
+
+	// 4. Compare the extraction cache with the intact layers
+	extractions, err := s.verifyExtracted(ctx, layers, blobs)
+	if err != nil {
+		return nil, err
+	}
+	report.Problems = append(report.Problems, extractions...)
+
+	if !repair {
+		return report, nil
+	}
+
+	// 5. Repair: drop damaged entries from the index and delete corrupt blobs
+	if len(kept) != len(index.Manifests) {
+		index.Manifests = kept
+		if err := s.backend.WriteIndex(ctx, index); err != nil {
+			return report, fmt.Errorf("failed to rewrite index: %w", err)
+		}
+	}
+
+	for _, d := range sortedDigests(blobs) {
+		if blobs[d].valid {
+			continue
+		}
+		if err := s.backend.DeleteBlob(ctx, d); err != nil {
+			return report, fmt.Errorf("failed to remove corrupt blob %s: %w", d, err)
+		}
+		report.RemovedBlobs = append(report.RemovedBlobs, d)
+	}
+
+	for _, p := range extractions {
+		if err := os.RemoveAll(s.ExtractDir(p.Digest)); err != nil {
+			return report, fmt.Errorf("failed to remove cached extraction %s: %w", p.Digest, err)
+		}
+		report.RemovedExtractions = append(report.RemovedExtractions, p.Digest)
+	}
+
+	return report, nil
+}
+
+// verifyManifest checks the manifest described by desc and the blobs it references,
+// marking them as reachable and recording its layers.
+func (s *Store) verifyManifest(ctx context.Context, desc ocispec.Descriptor, blobs map[digest.Digest]blobState, reachable map[digest.Digest]bool, layers map[digest.Digest]ocispec.Descriptor) []Problem {
+	reachable[desc.Digest] = true
+	if p := checkBlob(desc, blobs); p != nil {
+		return []Problem{*p}
+	}
+
+	manifestBytes, err := s.readBlob(ctx, desc.Digest)
+	if err != nil {
+		return []Problem{{Kind: ProblemMissingBlob, Digest: desc.Digest, Detail: err.Error()}}
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return []Problem{{Kind: ProblemInvalidManifest, Digest: desc.Digest, Detail: err.Error()}}
+	}
+	if manifest.Config.Digest == "" {
+		return []Problem{{Kind: ProblemInvalidManifest, Digest: desc.Digest, Detail: "manifest has no config"}}
+	}
+
+	for _, layer := range manifest.Layers {
+		layers[layer.Digest] = layer
+	}
+
+	var problems []Problem
+	for _, child := range append([]ocispec.Descriptor{manifest.Config}, manifest.Layers...) {
+		reachable[child.Digest] = true
+		if p := checkBlob(child, blobs); p != nil {
+			problems = append(problems, *p)
+		}
+	}
+	return problems
+}
+
+// checkBlob compares the blob on disk with the descriptor referencing it.
+func checkBlob(desc ocispec.Descriptor, blobs map[digest.Digest]blobState) *Problem {
+	state, ok := blobs[desc.Digest]
+	switch {
+	case !ok:
+		return &Problem{Kind: ProblemMissingBlob, Digest: desc.Digest, Detail: "blob not found"}
+	case state.size < desc.Size:
+		return &Problem{Kind: ProblemTruncatedBlob, Digest: desc.Digest, Detail: fmt.Sprintf("expected %d bytes, found %d", desc.Size, state.size)}
+	case state.size != desc.Size:
+		return &Problem{Kind: ProblemCorruptBlob, Digest: desc.Digest, Detail: fmt.Sprintf("expected %d bytes, found %d", desc.Size, state.size)}
+	case !state.valid:
+		return &Problem{Kind: ProblemCorruptBlob, Digest: desc.Digest, Detail: "content does not match digest"}
+	}
+	return nil
+}
+
+// hashBlobs re-hashes every blob in the store.
+func (s *Store) hashBlobs(ctx context.Context) (map[digest.Digest]blobState, error) {
+	blobs := make(map[digest.Digest]blobState)
+
+	infos, err := s.backend.Blobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if info.Digest.Validate() != nil {
+			// Not a valid digest; the content cannot possibly match.
+			blobs[info.Digest] = blobState{size: info.Size}
+			continue
+		}
+
+		rc, err := s.backend.Fetch(ctx, ocispec.Descriptor{Digest: info.Digest, Size: info.Size})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blob %s: %w", info.Digest, err)
+		}
+		verifier := info.Digest.Verifier()
+		n, err := io.Copy(verifier, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blob %s: %w", info.Digest, err)
+		}
+		blobs[info.Digest] = blobState{size: n, valid: verifier.Verified()}
+	}
+	return blobs, nil
+}
+
+// readBlob reads a blob by digest, whatever its size.
+func (s *Store) readBlob(ctx context.Context, d digest.Digest) ([]byte, error) {
+	rc, err := s.backend.Fetch(ctx, ocispec.Descriptor{Digest: d})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func sortedDigests(blobs map[digest.Digest]blobState) []digest.Digest {
+	digests := make([]digest.Digest, 0, len(blobs))
+	for d := range blobs {
+		digests = append(digests, d)
+	}
+	sort.Slice(digests, func(i, j int) bool { return digests[i] < digests[j] })
+	return digests
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	desc, err := st.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	report, err := st.Verify(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, report.Healthy())
+	assert.Equal(t, 3, report.BlobsChecked)
+	assert.Equal(t, 1, report.TagsChecked)
+
+	// Truncate the layer
+	manifestBytes, err := os.ReadFile(st.backend.(*FilesystemBackend).blobPath(desc.Digest))
+	require.NoError(t, err)
+	var manifest ocispec.Manifest
+	require.NoError(t, json.Unmarshal(manifestBytes, &manifest))
+	layerPath := st.backend.(*FilesystemBackend).blobPath(manifest.Layers[0].Digest)
+	require.NoError(t, os.Truncate(layerPath, 10))
+
+	report, err = st.Verify(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, report.Problems, 1)
+	assert.Equal(t, ProblemTruncatedBlob, report.Problems[0].Kind)
+	assert.Equal(t, "test:v1", report.Problems[0].Tag)
+
+	// Repair drops the tag and the corrupt blob
+	report, err = st.Verify(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test:v1"}, report.RemovedTags)
+	assert.Len(t, report.RemovedBlobs, 1)
+
+	_, err = st.Resolve(ctx, "test:v1")
+	assert.Error(t, err)
+
+	// Rebuilding restores a healthy store
+	_, err = st.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+	report, err = st.Verify(ctx, false)
+	require.NoError(t, err)
+	assert.True(t, report.Healthy(), "%v", report.Problems)
+}
+
+func TestVerify_CorruptManifest(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	ctx := context.Background()
+
+	srcDir := t.TempDir()
+	writeSkill(t, srcDir)
+
+	st, err := New(t.TempDir())
+	require.NoError(t, err)
+	desc, err := st.Build(ctx, srcDir, "test:v1", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(st.backend.(*FilesystemBackend).blobPath(desc.Digest), []byte("{not json"), 0644))
+
+	// The index can no longer be loaded, but Verify still runs.
+	_, err = st.List(ctx)
+	assert.Error(t, err)
+
+	report, err := st.Verify(ctx, true)
+	require.NoError(t, err)
+	assert.False(t, report.Healthy())
+	assert.Equal(t, []string{"test:v1"}, report.RemovedTags)
+
+	_, err = st.List(ctx)
+	assert.NoError(t, err)
+}
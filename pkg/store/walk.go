@@ -0,0 +1,182 @@
+package store
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/andrewhowdencom/skr/pkg/ignore"
+)
+
+// LinkPolicy controls how symbolic links in a skill directory are packaged.
+type LinkPolicy string
+
+const (
+	// LinksPreserve stores symbolic links as links. Every link must resolve inside the skill directory.
+	LinksPreserve LinkPolicy = "preserve"
+	// LinksFollow replaces symbolic links with the files or directories they point to.
+	LinksFollow LinkPolicy = "follow"
+)
+
+// ParseLinkPolicy converts a flag value into a LinkPolicy.
+func ParseLinkPolicy(value string) (LinkPolicy, error) {
+	switch p := LinkPolicy(value); p {
+	case LinksPreserve, LinksFollow:
+		return p, nil
+	case "":
+		return LinksPreserve, nil
+	default:
+		return "", fmt.Errorf("unknown link policy %q (expected %q or %q)", value, LinksPreserve, LinksFollow)
+	}
+}
+
+func newBuildOptions(opts []BuildOption) buildOptions {
+	options := buildOptions{links: LinksPreserve, compression: CompressionGzip}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// entry is a single file system object to be packaged.
+type entry struct {
+	name     string      // path relative to the skill root, in archive order
+	path     string      // path to read the content from
+	info     fs.FileInfo // metadata of the object (of the link target when following)
+	linkname string      // symlink target, when preserving links
+}
+
+// Files returns the paths relative to srcDir, in archive order, that Build would package.
+// Paths excluded by the default ignore rules or srcDir/.skrignore are omitted.
+func Files(srcDir string, opts ...BuildOption) ([]string, error) {
+	entries, err := collect(srcDir, newBuildOptions(opts).links)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.name
+	}
+	return paths, nil
+}
+
+// collect walks srcDir and returns the entries to package, sorted by name.
+func collect(srcDir string, policy LinkPolicy) ([]entry, error) {
+	matcher, err := ignore.Load(srcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.EvalSymlinks(srcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+
+	// ancestors holds the real paths of the directories being walked, to detect
+	// symlink cycles when following links.
+	ancestors := map[string]bool{root: true}
+
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		children, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			name := filepath.Join(rel, child.Name())
+			e := entry{name: name, path: filepath.Join(dir, child.Name())}
+
+			e.info, err = os.Lstat(e.path)
+			if err != nil {
+				return err
+			}
+
+			if e.info.Mode()&fs.ModeSymlink != 0 {
+				if policy == LinksFollow {
+					resolved, err := filepath.EvalSymlinks(e.path)
+					if err != nil {
+						return fmt.Errorf("failed to follow symlink %s: %w", name, err)
+					}
+					if e.info, err = os.Stat(resolved); err != nil {
+						return err
+					}
+					e.path = resolved
+				} else {
+					if e.linkname, err = linkTarget(root, e.path); err != nil {
+						return fmt.Errorf("symlink %s: %w", name, err)
+					}
+				}
+			}
+
+			if matcher.Match(name, e.info.IsDir()) {
+				continue
+			}
+			entries = append(entries, e)
+
+			if e.info.IsDir() {
+				real, err := filepath.EvalSymlinks(e.path)
+				if err != nil {
+					return err
+				}
+				if ancestors[real] {
+					return fmt.Errorf("symlink cycle detected at %s", name)
+				}
+				ancestors[real] = true
+				if err := walk(e.path, name); err != nil {
+					return err
+				}
+				delete(ancestors, real)
+			}
+		}
+		return nil
+	}
+
+	if err := walk(srcDir, ""); err != nil {
+		return nil, err
+	}
+
+	// Sort on the slash-separated name so the order is identical regardless of the
+	// host path separator.
+	sort.Slice(entries, func(i, j int) bool {
+		return filepath.ToSlash(entries[i].name) < filepath.ToSlash(entries[j].name)
+	})
+	return entries, nil
+}
+
+// linkTarget returns the target of the symlink at path as a slash-separated path relative
+// to the link, and checks that it resolves inside root.
+func linkTarget(root, path string) (string, error) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("broken link to %s", target)
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("link to %s points outside the skill directory (use the follow link policy to package its content)", target)
+	}
+
+	// Absolute links cannot survive installation; rewrite them relative to the link.
+	if filepath.IsAbs(target) {
+		parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+		if err != nil {
+			return "", err
+		}
+		if target, err = filepath.Rel(parent, resolved); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.ToSlash(target), nil
+}
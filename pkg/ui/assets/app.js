@@ -7,8 +7,11 @@ document.addEventListener('DOMContentLoaded', () => {
     const modal = document.getElementById('detail-modal');
     const modalTitle = document.getElementById('modal-title');
     const modalDesc = document.getElementById('modal-desc');
+    const modalMeta = document.getElementById('modal-meta');
     const installCmd = document.getElementById('install-cmd');
 
+    const SKILL_CONFIG_MEDIA_TYPE = 'application/vnd.agentskills.skill.config.v1+json';
+
     // Global state
     let allSkills = [];
     const host = window.location.host; // e.g. localhost:8080
@@ -49,11 +52,23 @@ document.addEventListener('DOMContentLoaded', () => {
                     const annotations = manifest.annotations || {};
                     const shortName = repo.split('/').pop();
 
+                    // Prefer the skill config; artifacts built by older versions only have annotations
+                    const config = await fetchConfig(repo, manifest);
+                    const frontmatter = (config && config.frontmatter) || {};
+                    const metadata = frontmatter.metadata || {};
+
                     return {
                         id: repo,
                         name: shortName,
-                        description: annotations['com.skr.description'] || 'No description available.',
-                        author: annotations['com.skr.author'] || 'Unknown Author',
+                        description: frontmatter.description || annotations['com.skr.description'] || 'No description available.',
+                        author: metadata.author || annotations['com.skr.author'] || 'Unknown Author',
+                        dependencies: (config && config.dependencies) || [],
+                        files: (config && config.files) || [],
+                        frontmatter: frontmatter,
+                        tokens: {
+                            body: annotations['com.skr.tokens.body'],
+                            references: annotations['com.skr.tokens.references']
+                        },
                         versions: tags.map(t => ({ version: t, tag: t })), // For now version == tag
                         latestTag: latestTag
                     };
@@ -75,6 +90,21 @@ document.addEventListener('DOMContentLoaded', () => {
         }
     }
 
+    // Fetch the skill config blob referenced by a manifest, if it has one
+    async function fetchConfig(repo, manifest) {
+        const desc = manifest.config || {};
+        if (desc.mediaType !== SKILL_CONFIG_MEDIA_TYPE || !desc.digest) return null;
+        try {
+            const res = await fetch(`/v2/${repo}/blobs/${desc.digest}`);
+            if (!res.ok) return null;
+            const config = await res.json();
+            return config.schemaVersion >= 1 ? config : null;
+        } catch (e) {
+            console.warn(`Failed to fetch config for ${repo}:`, e);
+            return null;
+        }
+    }
+
     // Search
     searchInput.addEventListener('input', (e) => {
         const term = e.target.value.toLowerCase();
@@ -146,6 +176,43 @@ document.addEventListener('DOMContentLoaded', () => {
         modalTitle.innerText = skill.name;
         modalDesc.innerText = skill.description;
 
+        const details = [];
+        if (skill.dependencies.length > 0) {
+            details.push(`Depends on: ${skill.dependencies.join(', ')}`);
+        }
+        if (skill.files.length > 0) {
+            details.push(`${skill.files.length} files`);
+        }
+
+        // Optional frontmatter fields, and any keys skr does not know about
+        const lines = [];
+        const known = ['name', 'description', 'license', 'compatibility', 'allowed-tools', 'metadata', 'dependencies'];
+        const fm = skill.frontmatter || {};
+        if (fm.license) lines.push(`License: ${fm.license}`);
+        if (fm.compatibility) lines.push(`Compatibility: ${fm.compatibility}`);
+        if (fm['allowed-tools']) {
+            const tools = fm['allowed-tools'];
+            lines.push(`Allowed tools: ${Array.isArray(tools) ? tools.join(' ') : tools}`);
+        }
+        Object.keys(fm.metadata || {}).sort().forEach(k => {
+            lines.push(`${k}: ${fm.metadata[k]}`);
+        });
+        Object.keys(fm).filter(k => !known.includes(k)).sort().forEach(k => {
+            const value = fm[k];
+            lines.push(`${k}: ${typeof value === 'object' ? JSON.stringify(value) : value}`);
+        });
+
+        if (skill.tokens.body !== undefined) {
+            details.push(`~${skill.tokens.body} tokens`);
+            if (skill.tokens.references && skill.tokens.references !== '0') {
+                details.push(`~${skill.tokens.references} tokens in references`);
+            }
+        }
+        if (details.length > 0) {
+            lines.push(details.join(' · '));
+        }
+        modalMeta.innerText = lines.join('\n');
+
         // Construct install command
         // Convention: host/repo:tag
         // Note: oras pull host/repo:tag